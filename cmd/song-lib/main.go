@@ -1,11 +1,10 @@
 package main
 
 import (
+	"context"
 	"effectivemobiletesttask/internal/app"
 	"effectivemobiletesttask/internal/config"
-	"effectivemobiletesttask/internal/migrator"
 	"effectivemobiletesttask/internal/utils/logger"
-	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -23,28 +22,53 @@ import (
 
 // @host 127.0.0.1:8000
 // @BasePath /
-func main() {
-	cfg := config.MustLoad()
 
-	log := logger.SetupLogger(cfg.Env)
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key created with POST /admin/api-keys
 
-	log.Info("start 'song library' application")
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT signed with HS256 or RS256: "Bearer <token>"
 
-	migrator.RunMigrations(&cfg.Storage, &cfg.Migrations)
+// @security ApiKeyAuth
+// @security BearerAuth
+func main() {
+	os.Exit(run())
+}
 
-	application := app.New(log, cfg)
+// Exit codes of the process.
+const (
+	exitOK      = 0
+	exitFailure = 1
+)
 
-	application.HTTPserver.MustRun()
+// run runs the application until SIGTERM or SIGINT and returns the exit
+// code: exitFailure when the application failed to start, failed while
+// running or did not shut down cleanly.
+func run() int {
+	cfg := config.MustLoad()
 
-	stop := make(chan os.Signal, 1)
+	log := logger.SetupLogger(cfg.Env)
 
-	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	log.Info("start 'song library' application")
 
-	sign := <-stop
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 
-	log.Info("stopping application", slog.String("signal:", sign.String()))
+	application, err := app.New(ctx, log, cfg)
+	if err != nil {
+		log.Error("failed to build application", logger.Err(err))
+		return exitFailure
+	}
 
-	application.HTTPserver.Stop()
+	if err := application.Run(ctx); err != nil {
+		log.Error("application stopped with error", logger.Err(err))
+		return exitFailure
+	}
 
 	log.Info("server is dead")
+	return exitOK
 }
@@ -1,34 +1,271 @@
 package app
 
 import (
+	"context"
+	enrichmentapp "effectivemobiletesttask/internal/app/enrichment"
 	httpapp "effectivemobiletesttask/internal/app/http"
+	"effectivemobiletesttask/internal/app/lifecycle"
+	trashapp "effectivemobiletesttask/internal/app/trash"
 	"effectivemobiletesttask/internal/config"
-	server "effectivemobiletesttask/internal/http-server/song"
+	httpserver "effectivemobiletesttask/internal/http-server"
+	adminserver "effectivemobiletesttask/internal/http-server/admin"
+	apikeyserver "effectivemobiletesttask/internal/http-server/apikey"
+	authzserver "effectivemobiletesttask/internal/http-server/authz"
+	groupserver "effectivemobiletesttask/internal/http-server/group"
+	healthserver "effectivemobiletesttask/internal/http-server/health"
+	songserver "effectivemobiletesttask/internal/http-server/song"
+	trashserver "effectivemobiletesttask/internal/http-server/trash"
+	"effectivemobiletesttask/internal/migrator"
+	"effectivemobiletesttask/internal/services/auth"
+	"effectivemobiletesttask/internal/services/health"
+	"effectivemobiletesttask/internal/services/metadata"
+	"effectivemobiletesttask/internal/services/policy"
 	service "effectivemobiletesttask/internal/services/song"
 	"effectivemobiletesttask/internal/storage/postgres"
 	"effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/ratelimit"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"fmt"
 	"log/slog"
+	"time"
 )
 
+// connectTimeout bounds a single attempt to reach the database on start.
+const connectTimeout = 5 * time.Second
+
 type App struct {
 	HTTPserver *httpapp.App
+	Enrichment *enrichmentapp.App
+	Trash      *trashapp.App
+	Storage    *postgres.Storage
+	lifecycle  *lifecycle.Manager
 }
 
+// New connects to the database, migrates it and builds the components.
+// Cancelling ctx aborts the start.
 func New(
+	ctx context.Context,
+	log *slog.Logger,
+	cfg *config.Config,
+) (*App, error) {
+	const op = "app.New"
+
+	stopTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	storage, err := connect(ctx, log, cfg.Storage)
+	if err != nil {
+		stopTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := migrator.RunMigrations(log, &cfg.Storage, &cfg.Migrations); err != nil {
+		storage.Close()
+		stopTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	application, err := build(ctx, log, cfg, storage, stopTracing)
+	if err != nil {
+		storage.Close()
+		stopTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return application, nil
+}
+
+func build(
+	ctx context.Context,
 	log *slog.Logger,
 	cfg *config.Config,
-) *App {
-	storage, err := postgres.New(cfg.Storage)
+	storage *postgres.Storage,
+	stopTracing func(context.Context) error,
+) (*App, error) {
+	metadataChain, err := metadata.NewRegistry(cfg.Client).Build(log, cfg.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizer, err := policy.New(ctx, log, storage, cfg.Authz, cfg.Auth.Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	var songMetadata service.MetadataProvider = metadataChain
+	routers := []httpapp.Router{authzserver.New(log, cfg.PageSize, authorizer)}
+
+	if cfg.Metadata.Cache.Enabled {
+		var cacheStore metadata.CacheStore
+		if cfg.Metadata.Cache.Persistent {
+			cacheStore = storage
+		}
+
+		metadataCache := metadata.NewCache(log, metadataChain, cacheStore, cfg.Metadata.Cache)
+		songMetadata = metadataCache
+		routers = append(routers, adminserver.New(log, cfg.PageSize, policy.NewMetadataCache(authorizer, metadataCache)))
+	}
+
+	service := service.New(log, storage, songMetadata, cfg.Enrichment, cfg.Trash)
+	songPolicy := policy.NewSongService(authorizer, service)
+	songServer := songserver.New(log, cfg.PageSize, songPolicy)
+	groupServer := groupserver.New(log, cfg.PageSize, songPolicy)
+	trashServer := trashserver.New(log, cfg.PageSize, songPolicy)
+	routers = append(routers, songServer, groupServer, trashServer)
+
+	authService, err := auth.New(log, storage, cfg.Auth)
 	if err != nil {
-		log.Error("failed connect to db err: %s", logger.Err(err))
+		return nil, err
 	}
+	routers = append(routers, apikeyserver.New(log, cfg.PageSize, policy.NewKeyService(authorizer, authService)))
 
-	service := service.New(log, storage, cfg.Client)
-	server := server.New(log, cfg.PageSize, service)
+	metrics.Registry.MustRegister(storage.Collectors(cfg.Storage.DBName)...)
 
-	app := httpapp.New(log, &cfg.Server, server)
+	migrations, err := migrator.NewChecker(&cfg.Storage, &cfg.Migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	// The metadata providers only feed the enrichment jobs, which retry, so
+	// the service stays ready without them.
+	healthService := health.New(log, cfg.Health.CheckTimeout)
+	healthService.Add("postgres", true, storage.Ping)
+	healthService.Add("migrations", true, func(context.Context) error { return migrations.Check() })
+	for name, pinger := range metadataChain.Pingers() {
+		healthService.Add("metadata:"+name, false, pinger.Ping)
+	}
+	routers = append(routers, healthserver.New(log, healthService))
+
+	var authenticator httpserver.Authenticator
+	if cfg.Auth.Enabled {
+		authenticator = authService
+	} else {
+		log.Warn("authentication is disabled, every endpoint is public and every operation is permitted")
+	}
+
+	var limiter httpserver.RateLimiter
+	var rateLimiter *ratelimit.Limiter
+	if cfg.Server.RateLimit.Enabled {
+		rateLimiter = newRateLimiter(log, cfg.Server.RateLimit, storage)
+		limiter = rateLimiter
+	} else {
+		log.Warn("rate limiting is disabled")
+	}
+
+	app := httpapp.New(log, &cfg.Server, authenticator, limiter, routers...)
+	enrichment := enrichmentapp.New(log, cfg.Enrichment, service)
+	trash := trashapp.New(log, cfg.Trash, service, authorizer, storage)
+
+	// Components start in this order and stop in reverse: the HTTP server
+	// drains first, then the workers finish their jobs, the pool closes, and
+	// the spans recorded until then are flushed last.
+	manager := lifecycle.New(log, cfg.Server.ShutdownTimeout)
+	manager.Add(lifecycle.Component{
+		Name: "tracing",
+		Stop: stopTracing,
+	})
+	manager.Add(lifecycle.Component{
+		Name: "storage",
+		Stop: func(context.Context) error { return storage.Close() },
+	})
+	manager.Add(lifecycle.Component{
+		Name: "migrations",
+		Stop: func(context.Context) error { return migrations.Close() },
+	})
+	manager.Add(lifecycle.Component{
+		Name:  "enrichment",
+		Start: func() error { enrichment.Start(); return nil },
+		Stop:  enrichment.Stop,
+	})
+	manager.Add(lifecycle.Component{
+		Name:  "trash",
+		Start: func() error { trash.Start(); return nil },
+		Stop:  trash.Stop,
+	})
+	if rateLimiter != nil {
+		manager.Add(lifecycle.Component{
+			Name:  "ratelimit",
+			Start: func() error { rateLimiter.Start(); return nil },
+			Stop:  rateLimiter.Stop,
+		})
+	}
+	manager.Add(lifecycle.Component{
+		Name: "http",
+		Run:  app.Run,
+		Stop: app.Stop,
+	})
 
 	return &App{
 		HTTPserver: app,
+		Enrichment: enrichment,
+		Trash:      trash,
+		Storage:    storage,
+		lifecycle:  manager,
+	}, nil
+}
+
+// Run runs the application until ctx is done or a component fails, then
+// shuts it down. It returns nil after a clean shutdown.
+func (a *App) Run(ctx context.Context) error {
+	return a.lifecycle.Run(ctx)
+}
+
+func newRateLimiter(log *slog.Logger, cfg config.RateLimit, storage *postgres.Storage) *ratelimit.Limiter {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.Store == "postgres" {
+		store = storage
+	}
+
+	bucket := func(b config.RateLimitBucket) ratelimit.Bucket {
+		return ratelimit.NewBucket(b.Requests, b.Per, b.Burst)
+	}
+
+	return ratelimit.New(log, store, map[string]ratelimit.Bucket{
+		httpserver.RateLimitRead:       bucket(cfg.Read),
+		httpserver.RateLimitWrite:      bucket(cfg.Write),
+		httpserver.RateLimitEnrichment: bucket(cfg.Enrichment),
+	})
+}
+
+// connect opens the database and pings it until it answers, retrying with a
+// doubling backoff, so that a database that is still starting up does not
+// fail the service while one that stays unreachable does.
+func connect(ctx context.Context, log *slog.Logger, cfg config.DBStorage) (*postgres.Storage, error) {
+	storage, err := postgres.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := cfg.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+		err := storage.Ping(pingCtx)
+		cancel()
+
+		if err == nil {
+			return storage, nil
+		}
+
+		if attempt >= cfg.ConnectRetries {
+			storage.Close()
+			return nil, err
+		}
+
+		log.Warn("database is unreachable, retrying",
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", backoff),
+			logger.Err(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			storage.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
 }
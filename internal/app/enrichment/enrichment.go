@@ -0,0 +1,102 @@
+package enrichmentapp
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/utils/logger"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Processor interface {
+	ProcessNextEnrichment(ctx context.Context) (bool, error)
+}
+
+// App is a pool of workers draining the enrichment job queue. Each worker
+// claims jobs one by one and sleeps for the poll interval once the queue is
+// empty.
+type App struct {
+	log       *slog.Logger
+	cfg       config.Enrichment
+	processor Processor
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func New(log *slog.Logger, cfg config.Enrichment, processor Processor) *App {
+	return &App{
+		log:       log,
+		cfg:       cfg,
+		processor: processor,
+	}
+}
+
+func (a *App) Start() {
+	const op = "app.enrichment.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	a.log.With(slog.String("op", op)).Info("starting enrichment workers", slog.Int("workers", a.cfg.Workers))
+
+	for i := 0; i < a.cfg.Workers; i++ {
+		a.wg.Add(1)
+		go a.work(ctx, i)
+	}
+}
+
+// Stop cancels the workers and waits for the jobs in progress, until ctx is
+// done. A job cut short keeps its lease and is picked up again once the
+// lease expires.
+func (a *App) Stop(ctx context.Context) error {
+	const op = "app.enrichment.Stop"
+
+	if a.cancel == nil {
+		return nil
+	}
+
+	a.log.With(slog.String("op", op)).Info("stopping enrichment workers")
+
+	a.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
+
+func (a *App) work(ctx context.Context, worker int) {
+	defer a.wg.Done()
+
+	log := a.log.With(slog.Int("worker", worker))
+
+	for {
+		processed, err := a.processor.ProcessNextEnrichment(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "error processing enrichment job", logger.Err(err))
+		}
+
+		if processed && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.cfg.PollInterval):
+		}
+	}
+}
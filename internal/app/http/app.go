@@ -1,12 +1,17 @@
 package httpapp
 
 import (
+	"context"
 	"effectivemobiletesttask/internal/config"
-	"effectivemobiletesttask/internal/http-server/song"
+	httpserver "effectivemobiletesttask/internal/http-server"
 	"effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
 	_ "effectivemobiletesttask/cmd/song-lib/docs"
 
@@ -14,26 +19,70 @@ import (
 	swagger "github.com/swaggo/http-swagger"
 )
 
+type Router interface {
+	RegisterRoutes(mux *http.ServeMux)
+}
+
 type App struct {
 	log        *slog.Logger
 	cfg        *config.HTTPServer
 	httpServer *http.Server
 }
 
-func New(log *slog.Logger, cfg *config.HTTPServer, server *song.Server) *App {
+// publicPaths are the path prefixes that need no authentication and are not
+// rate limited: the Swagger UI, the probes and the metrics.
+var publicPaths = []string{"/swagger/", "/healthz", "/readyz", "/metrics"}
+
+// New builds the HTTP server. With a nil auth every endpoint is public,
+// otherwise everything except the public paths requires authentication. With
+// a nil limiter clients are not rate limited. Authenticated requests are
+// limited by API key or token subject, the others by IP, failed
+// authentications included so that guessing credentials is throttled. Every
+// request takes a single token.
+func New(
+	log *slog.Logger,
+	cfg *config.HTTPServer,
+	auth httpserver.Authenticator,
+	limiter httpserver.RateLimiter,
+	routers ...Router,
+) *App {
 	mux := http.NewServeMux()
 
+	classify := routeClass(mux, cfg.RateLimit.EnrichmentRoutes)
+
+	var handler http.Handler = mux
+	switch {
+	case auth != nil && limiter != nil:
+		handler = httpserver.RateLimit(log, limiter, classify, httpserver.ClientPrincipal)(handler)
+		handler = httpserver.Authenticate(auth, publicPaths...)(handler)
+		handler = httpserver.RateLimitUnauthenticated(log, limiter, classify)(handler)
+	case auth != nil:
+		handler = httpserver.Authenticate(auth, publicPaths...)(handler)
+	case limiter != nil:
+		handler = httpserver.RateLimit(log, limiter, classify, httpserver.ClientIP)(handler)
+	}
+	handler = httpserver.Trace(routeLabel(mux))(handler)
+	handler = httpserver.Instrument(routeLabel(mux))(handler)
+
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Content-Length"},
-		AllowCredentials: true,
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{
+			"Authorization", "Content-Type", "If-Match", "If-None-Match", httpserver.APIKeyHeader,
+		},
+		ExposedHeaders: []string{
+			"Content-Length", "ETag", "Accept-Patch", "WWW-Authenticate",
+			"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+		},
+		AllowCredentials: !slices.Contains(cfg.CORS.AllowedOrigins, "*"),
 	})
-	handler := corsHandler.Handler(mux)
+	handler = corsHandler.Handler(handler)
 
 	mux.HandleFunc("/swagger/", swagger.WrapHandler)
-	server.RegisterRoutes(mux)
+	mux.Handle("GET /metrics", metrics.Handler())
+	for _, router := range routers {
+		router.RegisterRoutes(mux)
+	}
 
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
@@ -50,9 +99,43 @@ func New(log *slog.Logger, cfg *config.HTTPServer, server *song.Server) *App {
 	}
 }
 
-func (a *App) MustRun() {
-	if err := a.Run(); err != nil {
-		panic(err)
+// routeClass classes requests by the route they match: routes that start
+// enrichment, other reads and other writes. Public paths are not limited.
+func routeClass(mux *http.ServeMux, enrichmentRoutes []string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		for _, prefix := range publicPaths {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return ""
+			}
+		}
+
+		if _, pattern := mux.Handler(r); slices.Contains(enrichmentRoutes, pattern) {
+			return httpserver.RateLimitEnrichment
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return httpserver.RateLimitRead
+		default:
+			return httpserver.RateLimitWrite
+		}
+	}
+}
+
+// routeLabel names requests in the metrics and traces by the route pattern
+// they match, without the method, so that path parameters do not multiply
+// the series.
+func routeLabel(mux *http.ServeMux) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			return "unmatched"
+		}
+
+		if _, path, ok := strings.Cut(pattern, " "); ok {
+			return path
+		}
+		return pattern
 	}
 }
 
@@ -67,19 +150,29 @@ func (a *App) Run() error {
 
 	log.Info("starting HTTP server")
 
-	if err := a.httpServer.ListenAndServe(); err != nil {
+	if err := a.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
-func (a *App) Stop() {
+// Stop stops accepting connections and waits for the requests in flight to
+// finish. Connections still open when ctx is done are closed.
+func (a *App) Stop(ctx context.Context) error {
 	const op = "app.http.Stop"
 
 	a.log.With(slog.String("op", op)).Info("Stopping HTTP server", slog.Int("port", a.cfg.Port))
 
-	if err := a.httpServer.Close(); err != nil {
-		a.log.Error("error while closing HTTP server", logger.Err(err))
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		a.log.Error("HTTP server did not drain in time, closing connections", logger.Err(err))
+
+		if err := a.httpServer.Close(); err != nil {
+			a.log.Error("error while closing HTTP server", logger.Err(err))
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
 	}
+
+	return nil
 }
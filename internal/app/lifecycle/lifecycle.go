@@ -0,0 +1,123 @@
+// Package lifecycle starts the components of the application in order and
+// stops them in reverse order once the application is told to stop or one of
+// its components fails.
+package lifecycle
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/utils/logger"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Component is a part of the application with a lifetime of its own. Every
+// hook is optional. Start must not block; Run serves until the component is
+// stopped and runs in its own goroutine; Stop releases the component and
+// should give up when ctx is done.
+type Component struct {
+	Name  string
+	Start func() error
+	Run   func() error
+	Stop  func(ctx context.Context) error
+}
+
+type Manager struct {
+	log             *slog.Logger
+	shutdownTimeout time.Duration
+	components      []Component
+}
+
+// New returns a manager that gives its components shutdownTimeout in total
+// to stop.
+func New(log *slog.Logger, shutdownTimeout time.Duration) *Manager {
+	return &Manager{
+		log:             log,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// Add appends a component. Components start in the order they are added.
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+// Run starts the components and blocks until ctx is done or a component
+// fails to start or stops running, then stops the started components. The
+// returned error joins the failure and the errors of stopping, nil after a
+// clean shutdown.
+func (m *Manager) Run(ctx context.Context) error {
+	const op = "app.lifecycle.Run"
+
+	log := m.log.With(slog.String("op", op))
+
+	failed := make(chan error, len(m.components))
+	started := make([]Component, 0, len(m.components))
+
+	var runErr error
+
+	for _, c := range m.components {
+		if c.Start != nil {
+			if err := c.Start(); err != nil {
+				runErr = fmt.Errorf("%s: start %s: %w", op, c.Name, err)
+				break
+			}
+		}
+		started = append(started, c)
+
+		if c.Run != nil {
+			go func(c Component) {
+				err := c.Run()
+				if err == nil {
+					err = errors.New("stopped unexpectedly")
+				}
+				failed <- fmt.Errorf("%s: run %s: %w", op, c.Name, err)
+			}(c)
+		}
+
+		log.Debug("component started", slog.String("component", c.Name))
+	}
+
+	if runErr == nil {
+		select {
+		case <-ctx.Done():
+			log.Info("stopping application")
+		case runErr = <-failed:
+		}
+	}
+
+	if runErr != nil {
+		log.Error("stopping application after failure", logger.Err(runErr))
+	}
+
+	return errors.Join(runErr, m.stop(started))
+}
+
+// stop stops the components in reverse order within the shutdown timeout.
+// Components still get their turn once the timeout passed, with a done ctx,
+// so that each of them can release what it holds.
+func (m *Manager) stop(started []Component) error {
+	const op = "app.lifecycle.stop"
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var stopErrs []error
+
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop == nil {
+			continue
+		}
+
+		if err := c.Stop(ctx); err != nil {
+			stopErrs = append(stopErrs, fmt.Errorf("%s: stop %s: %w", op, c.Name, err))
+			continue
+		}
+
+		m.log.Debug("component stopped", slog.String("component", c.Name))
+	}
+
+	return errors.Join(stopErrs...)
+}
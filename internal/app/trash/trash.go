@@ -0,0 +1,116 @@
+package trashapp
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/utils/logger"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Purger interface {
+	PurgeTrash(ctx context.Context) (models.PurgeResult, error)
+}
+
+type DenialPurger interface {
+	PurgeDenials(ctx context.Context) (int64, error)
+}
+
+type CachePurger interface {
+	PurgeExpiredMetadataCache(ctx context.Context) (int64, error)
+}
+
+// App purges the trash, the old authorization denials and the expired
+// metadata cache entries once per purge interval, starting right away.
+type App struct {
+	log     *slog.Logger
+	cfg     config.Trash
+	purger  Purger
+	denials DenialPurger
+	cache   CachePurger
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func New(log *slog.Logger, cfg config.Trash, purger Purger, denials DenialPurger, cache CachePurger) *App {
+	return &App{
+		log:     log,
+		cfg:     cfg,
+		purger:  purger,
+		denials: denials,
+		cache:   cache,
+	}
+}
+
+func (a *App) Start() {
+	const op = "app.trash.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	a.log.With(slog.String("op", op)).Info(
+		"starting trash purge",
+		slog.Duration("retention", a.cfg.Retention),
+		slog.Duration("interval", a.cfg.PurgeInterval),
+	)
+
+	a.wg.Add(1)
+	go a.run(ctx)
+}
+
+// Stop cancels the purge job and waits for a purge in progress, until ctx
+// is done.
+func (a *App) Stop(ctx context.Context) error {
+	const op = "app.trash.Stop"
+
+	if a.cancel == nil {
+		return nil
+	}
+
+	a.log.With(slog.String("op", op)).Info("stopping trash purge")
+
+	a.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
+
+func (a *App) run(ctx context.Context) {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.purger.PurgeTrash(ctx); err != nil {
+			a.log.Error("error purging trash", logger.Err(err))
+		}
+
+		if _, err := a.denials.PurgeDenials(ctx); err != nil {
+			a.log.Error("error purging denials", logger.Err(err))
+		}
+
+		if _, err := a.cache.PurgeExpiredMetadataCache(ctx); err != nil {
+			a.log.Error("error purging expired metadata cache", logger.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
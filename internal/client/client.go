@@ -1,7 +1,12 @@
 package client
 
-import "errors"
+import (
+	"effectivemobiletesttask/internal/domain/errs"
+)
 
 var (
-	ErrSongNotFound = errors.New("song was not found")
+	ErrSongNotFound        = errs.New(errs.KindNotFound, "upstream_song_not_found", "song was not found in the song info service")
+	ErrUpstreamUnavailable = errs.New(errs.KindUnavailable, "upstream_unavailable", "upstream is unavailable")
+	ErrBadPayload          = errs.New(errs.KindUpstream, "upstream_bad_payload", "upstream returned bad payload")
+	ErrCircuitOpen         = errs.New(errs.KindUnavailable, "upstream_circuit_open", "circuit breaker is open").Wrap(ErrUpstreamUnavailable)
 )
@@ -2,61 +2,223 @@ package song
 
 import (
 	"bytes"
+	"context"
+	"effectivemobiletesttask/internal/client"
 	"effectivemobiletesttask/internal/config"
 	"effectivemobiletesttask/internal/domain/models"
 	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Client struct {
-	log *slog.Logger
-	api config.APIClient
+	log        *slog.Logger
+	api        config.APIClient
+	httpClient *http.Client
+	breaker    *breaker
 }
 
 func NewClient(log *slog.Logger, api config.APIClient) *Client {
 	return &Client{
-		log: log,
-		api: api,
+		log:        log,
+		api:        api,
+		httpClient: &http.Client{Timeout: api.Timeout},
+		breaker:    newBreaker(api.BreakerThreshold, api.BreakerCooldown),
 	}
 }
 
-func (c *Client) GetSongDetail(songReq models.SongRequest) (models.SongDetail, error) {
+func (c *Client) GetSongDetail(ctx context.Context, songReq models.SongRequest) (models.SongDetail, error) {
 	const op = "client.song.GetSongDetail"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	reqBody, err := json.Marshal(songReq)
 	if err != nil {
 		c.log.Error("error marshaling song request", lg.Err(err))
 		return models.SongDetail{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	for attempt := 0; ; attempt++ {
+		if !c.breaker.allow() {
+			c.log.Warn("circuit breaker is open, skipping request to API")
+			metrics.ObserveUpstream(outcome(client.ErrCircuitOpen), 0)
+			tracing.Fail(span, client.ErrCircuitOpen)
+			return models.SongDetail{}, fmt.Errorf("%s: %w", op, client.ErrCircuitOpen)
+		}
+
+		start := time.Now()
+		attemptCtx, attemptSpan := c.startAttempt(ctx, attempt)
+		songDetail, err := c.do(attemptCtx, reqBody)
+		metrics.ObserveUpstream(outcome(err), time.Since(start))
+		if err != nil {
+			tracing.Fail(attemptSpan, err)
+		}
+		attemptSpan.End()
+		if err == nil {
+			c.breaker.success()
+			return songDetail, nil
+		}
+
+		if !errors.Is(err, client.ErrUpstreamUnavailable) {
+			// The upstream answered, it is alive even if the answer is not usable.
+			c.breaker.success()
+			return models.SongDetail{}, fmt.Errorf("%s: %w", op, err)
+		}
+		c.breaker.failure()
+
+		if attempt >= c.api.MaxRetries || ctx.Err() != nil {
+			return models.SongDetail{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		delay := c.backoff(attempt)
+		c.log.Warn("request to API failed, retrying",
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+			lg.Err(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return models.SongDetail{}, fmt.Errorf("%s: %w", op, client.ErrUpstreamUnavailable.Wrap(ctx.Err()))
+		case <-time.After(delay):
+		}
+	}
+}
+
+// startAttempt starts the client span of a single request to the API.
+func (c *Client) startAttempt(ctx context.Context, attempt int) (context.Context, trace.Span) {
+	return tracing.Start(ctx, http.MethodGet+" "+c.api.Url,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodGet,
+			semconv.URLFull(c.api.Protocol+"://"+c.api.Address+c.api.Url),
+			semconv.HTTPRequestResendCount(attempt),
+		),
+	)
+}
+
+// outcome names the result of a request in the metrics.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, client.ErrSongNotFound):
+		return "not_found"
+	case errors.Is(err, client.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, client.ErrUpstreamUnavailable):
+		return "unavailable"
+	case errors.Is(err, client.ErrBadPayload):
+		return "bad_payload"
+	default:
+		return "error"
+	}
+}
+
+// Ping checks that the API answers. Any response below 500 counts, the probe
+// does not ask for a song and neither retries nor trips the breaker.
+func (c *Client) Ping(ctx context.Context) error {
+	const op = "client.song.Ping"
+
+	if c.breaker.isOpen() {
+		return fmt.Errorf("%s: %w", op, client.ErrCircuitOpen)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.api.Protocol+"://"+c.api.Address+c.api.Url, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, client.ErrUpstreamUnavailable.Wrap(err))
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s: %w", op, client.ErrUpstreamUnavailable.Withf("status %d", resp.StatusCode))
+	}
+
+	return nil
+}
+
+// do performs a single request. Network errors and 5xx responses are
+// reported as ErrUpstreamUnavailable so that they are retried.
+func (c *Client) do(ctx context.Context, reqBody []byte) (models.SongDetail, error) {
 	c.log.Debug("start preparing request")
-	req, err := http.NewRequest("GET", c.api.Protocol+"://"+c.api.Address+c.api.Url, bytes.NewReader(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "GET", c.api.Protocol+"://"+c.api.Address+c.api.Url, bytes.NewReader(reqBody))
 	if err != nil {
 		c.log.Error("error creating the HTTP request", lg.Err(err))
-		return models.SongDetail{}, fmt.Errorf("%s: %w", op, err)
+		return models.SongDetail{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
 	c.log.Debug("successfully prepared request")
 
 	c.log.Debug("start sending request to API")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		c.log.Error("error during the request to API", lg.Err(err))
-		return models.SongDetail{}, fmt.Errorf("%s: %w", op, err)
+		return models.SongDetail{}, client.ErrUpstreamUnavailable.Wrap(err)
 	}
 	defer resp.Body.Close()
-	c.log.Debug("successfully sent request to API")
+	c.log.Debug("successfully sent request to API", slog.Int("status", resp.StatusCode))
+	trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return models.SongDetail{}, client.ErrSongNotFound
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		io.Copy(io.Discard, resp.Body)
+		return models.SongDetail{}, client.ErrUpstreamUnavailable.Withf("status %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return models.SongDetail{}, client.ErrBadPayload.Withf("unexpected status %d", resp.StatusCode)
+	}
 
 	c.log.Debug("start fetching song detail")
 	var songDetail models.SongDetail
 	if err := json.NewDecoder(resp.Body).Decode(&songDetail); err != nil {
-		c.log.Error("error during the fetching song detail")
-		return models.SongDetail{}, fmt.Errorf("%s: %w", op, err)
+		c.log.Error("error during the fetching song detail", lg.Err(err))
+		return models.SongDetail{}, client.ErrBadPayload.Wrap(err)
 	}
 	c.log.Debug("fetched song detail: ", slog.Any("detail", songDetail))
 
 	return songDetail, nil
 }
+
+// backoff returns a full-jitter exponential delay for the given attempt.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := backoffCeiling(c.api.BackoffBase, c.api.BackoffMax, attempt)
+	if delay <= 0 {
+		return 0
+	}
+
+	return rand.N(delay)
+}
+
+// backoffCeiling returns base doubled attempt times, capped at limit. The
+// cap is checked before shifting, so a large base or attempt can't overflow
+// into a negative or short delay.
+func backoffCeiling(base time.Duration, limit time.Duration, attempt int) time.Duration {
+	if base <= 0 || limit <= 0 {
+		return 0
+	}
+
+	if attempt >= 63 || base > limit>>attempt {
+		return limit
+	}
+
+	return base << attempt
+}
@@ -0,0 +1,53 @@
+package song
+
+import (
+	"effectivemobiletesttask/internal/config"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBackoffCeiling(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    time.Duration
+		limit   time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{"first attempt", 100 * time.Millisecond, 2 * time.Second, 0, 100 * time.Millisecond},
+		{"doubles", 100 * time.Millisecond, 2 * time.Second, 1, 200 * time.Millisecond},
+		{"below the cap", 100 * time.Millisecond, 2 * time.Second, 4, 1600 * time.Millisecond},
+		{"exactly the cap", 125 * time.Millisecond, 2 * time.Second, 4, 2 * time.Second},
+		{"capped", 100 * time.Millisecond, 2 * time.Second, 5, 2 * time.Second},
+		{"shift past 32 bits", 100 * time.Millisecond, 2 * time.Second, 40, 2 * time.Second},
+		{"shift overflowing int64", 10 * time.Second, time.Hour, 31, time.Hour},
+		{"shift by the word size", time.Nanosecond, time.Hour, 64, time.Hour},
+		{"huge attempt", time.Nanosecond, time.Hour, math.MaxInt, time.Hour},
+		{"no cap overflow at the limit", time.Nanosecond, math.MaxInt64, 62, 1 << 62},
+		{"no base", 0, 2 * time.Second, 3, 0},
+		{"no cap", 100 * time.Millisecond, 0, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffCeiling(tt.base, tt.limit, tt.attempt); got != tt.want {
+				t.Errorf("backoffCeiling() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{api: config.APIClient{BackoffBase: 10 * time.Second, BackoffMax: time.Minute}}
+
+	for attempt := range 100 {
+		ceiling := backoffCeiling(c.api.BackoffBase, c.api.BackoffMax, attempt)
+
+		for range 20 {
+			if delay := c.backoff(attempt); delay < 0 || delay >= ceiling {
+				t.Fatalf("backoff(%d) = %v, want within [0, %v)", attempt, delay, ceiling)
+			}
+		}
+	}
+}
@@ -14,29 +14,179 @@ type Config struct {
 	Server     HTTPServer `yaml:"http_server" env-required:"true"`
 	Storage    DBStorage  `yaml:"storage" env-required:"true"`
 	Client     APIClient  `yaml:"api_client"`
+	Metadata   Metadata   `yaml:"metadata"`
+	Enrichment Enrichment `yaml:"enrichment"`
+	Trash      Trash      `yaml:"trash"`
+	Auth       Auth       `yaml:"auth"`
+	Authz      Authz      `yaml:"authorization"`
+	Health     Health     `yaml:"health"`
+	Tracing    Tracing    `yaml:"tracing"`
 	Migrations Migrations `yanl:"migrations"`
 }
 
+// HTTPServer configures the API server. ShutdownTimeout bounds the graceful
+// shutdown: draining the requests in flight, stopping the workers and
+// closing the database pool.
 type HTTPServer struct {
-	Host        string        `yaml:"host" env-default:"localhost" env-required:"true"`
-	Port        int           `yaml:"port" env-default:"5432" env-required:"true"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	Host            string        `yaml:"host" env-default:"localhost" env-required:"true"`
+	Port            int           `yaml:"port" env-default:"5432" env-required:"true"`
+	Timeout         time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+	CORS            CORS          `yaml:"cors"`
+	RateLimit       RateLimit     `yaml:"rate_limit"`
 }
 
+// CORS lists the origins browsers may call the API from. Credentials are
+// only allowed for explicitly listed origins, never together with "*".
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// RateLimit configures the token buckets that limit every client, identified
+// by API key, token subject or IP. Requests are classed as read (GET), write
+// (other methods) or enrichment (EnrichmentRoutes, which call the paid
+// metadata API), each class has its own bucket. Store is "memory" or
+// "postgres", which shares the buckets across replicas.
+type RateLimit struct {
+	Enabled          bool            `yaml:"enabled" env-default:"true"`
+	Store            string          `yaml:"store" env-default:"memory"`
+	Read             RateLimitBucket `yaml:"read"`
+	Write            RateLimitBucket `yaml:"write"`
+	Enrichment       RateLimitBucket `yaml:"enrichment"`
+	EnrichmentRoutes []string        `yaml:"enrichment_routes" env-default:"POST /song/create,POST /song/{id}/enrich"`
+}
+
+// RateLimitBucket allows Requests per Per on average and Burst at once. A
+// class without requests or burst is not limited.
+type RateLimitBucket struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per" env-default:"1m"`
+	Burst    int           `yaml:"burst"`
+}
+
+// DBStorage configures the database. On start the connection is tried
+// ConnectRetries more times, waiting ConnectBackoff, doubled after every
+// attempt, before the service gives up.
 type DBStorage struct {
-	Host    string `yaml:"host" env-default:"localhost" env-required:"true"`
-	Port    int    `yaml:"port" env-default:"5432" env-required:"true"`
-	DBName  string `yaml:"db_name" env-required:"true"`
-	User    string `yaml:"user" env-required:"true"`
-	Pass    string `yaml:"pass" env-required:"true"`
-	SSLMode string `yaml:"ssl_mode" env-default:"disable" env-required:"true"`
+	Host           string        `yaml:"host" env-default:"localhost" env-required:"true"`
+	Port           int           `yaml:"port" env-default:"5432" env-required:"true"`
+	DBName         string        `yaml:"db_name" env-required:"true"`
+	User           string        `yaml:"user" env-required:"true"`
+	Pass           string        `yaml:"pass" env-required:"true"`
+	SSLMode        string        `yaml:"ssl_mode" env-default:"disable" env-required:"true"`
+	ConnectRetries int           `yaml:"connect_retries" env-default:"5"`
+	ConnectBackoff time.Duration `yaml:"connect_backoff" env-default:"1s"`
 }
 
 type APIClient struct {
-	Address  string `yaml:"address" env-default:"localhost:3000"`
-	Protocol string `yaml:"protocol" env-default:"http"`
-	Url      string `yaml:"url" env-default:"/info"`
+	Address          string        `yaml:"address" env-default:"localhost:3000"`
+	Protocol         string        `yaml:"protocol" env-default:"http"`
+	Url              string        `yaml:"url" env-default:"/info"`
+	Timeout          time.Duration `yaml:"timeout" env-default:"5s"`
+	MaxRetries       int           `yaml:"max_retries" env-default:"3"`
+	BackoffBase      time.Duration `yaml:"backoff_base" env-default:"100ms"`
+	BackoffMax       time.Duration `yaml:"backoff_max" env-default:"2s"`
+	BreakerThreshold int           `yaml:"breaker_threshold" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env-default:"30s"`
+}
+
+// Metadata configures the providers that enrich songs. Providers are asked in
+// ascending priority order; Merge lists, per song field (releaseDate, text,
+// link), the providers allowed to fill it, most preferred first.
+type Metadata struct {
+	Providers []MetadataProvider  `yaml:"providers"`
+	Merge     map[string][]string `yaml:"merge"`
+	Cache     MetadataCache       `yaml:"cache"`
+}
+
+// MetadataCache configures the cache in front of the metadata providers.
+// Persistent adds a Postgres tier behind the in-memory LRU.
+type MetadataCache struct {
+	Enabled     bool          `yaml:"enabled" env-default:"true"`
+	Capacity    int           `yaml:"capacity" env-default:"1000"`
+	TTL         time.Duration `yaml:"ttl" env-default:"24h"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"1h"`
+	Persistent  bool          `yaml:"persistent" env-default:"false"`
+}
+
+type MetadataProvider struct {
+	Name     string       `yaml:"name"`
+	Kind     string       `yaml:"kind"`
+	Priority int          `yaml:"priority"`
+	Path     string       `yaml:"path"`
+	Stub     StubMetadata `yaml:"stub"`
+}
+
+type StubMetadata struct {
+	ReleaseDate string `yaml:"release_date"`
+	Text        string `yaml:"text"`
+	Link        string `yaml:"link"`
+}
+
+type Enrichment struct {
+	Workers         int           `yaml:"workers" env-default:"4"`
+	PollInterval    time.Duration `yaml:"poll_interval" env-default:"1s"`
+	MaxAttempts     int           `yaml:"max_attempts" env-default:"5"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env-default:"10s"`
+	RetryBackoffMax time.Duration `yaml:"retry_backoff_max" env-default:"10m"`
+	JobLease        time.Duration `yaml:"job_lease" env-default:"1m"`
+}
+
+// Trash configures how long deleted songs and groups can be restored before
+// the purge job removes them for good.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Auth configures how callers are authenticated. Requests carry either an API
+// key in the X-API-Key header or a bearer JWT. HS256 tokens are accepted when
+// a secret is set, RS256 tokens when a JWKS file is. BootstrapKey is an API
+// key accepted without being stored, to create the first stored keys.
+type Auth struct {
+	Enabled      bool   `yaml:"enabled" env-default:"true"`
+	BootstrapKey string `yaml:"bootstrap_key" env:"AUTH_BOOTSTRAP_KEY"`
+	JWT          JWT    `yaml:"jwt"`
+}
+
+type JWT struct {
+	Secret   string        `yaml:"secret" env:"AUTH_JWT_SECRET"`
+	JWKSFile string        `yaml:"jwks_file"`
+	Issuer   string        `yaml:"issuer"`
+	Audience string        `yaml:"audience"`
+	Leeway   time.Duration `yaml:"leeway" env-default:"30s"`
+}
+
+// Authz configures what authenticated callers may do. Roles maps a role to
+// the permissions it grants and replaces the built-in viewer, editor and
+// admin roles of the same name. Grants maps a subject, "key:<id>" for an API
+// key or "jwt:<sub>" for a token subject, to its roles. Both are written to
+// the database on start. The trash purge job also removes denials older than
+// DenialRetention.
+type Authz struct {
+	Roles           map[string][]string `yaml:"roles"`
+	Grants          map[string][]string `yaml:"grants"`
+	DenialRetention time.Duration       `yaml:"denial_retention" env-default:"720h"`
+}
+
+// Health configures the readiness checks. Each check has CheckTimeout to
+// complete.
+type Health struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env-default:"2s"`
+}
+
+// Tracing configures the OpenTelemetry traces. Exporter is "otlp", which
+// sends the spans over OTLP/HTTP to Endpoint, or "stdout", which prints them
+// for local runs. SampleRatio is the share of new traces that are recorded;
+// a request that arrives with a traceparent follows the caller's decision.
+type Tracing struct {
+	Enabled     bool    `yaml:"enabled" env-default:"false"`
+	ServiceName string  `yaml:"service_name" env-default:"song-lib"`
+	Exporter    string  `yaml:"exporter" env-default:"otlp"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env-default:"true"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
 }
 
 type Migrations struct {
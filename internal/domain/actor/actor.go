@@ -0,0 +1,25 @@
+// Package actor names whoever performs an operation, so that changes can be
+// attributed to them.
+package actor
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/principal"
+)
+
+const (
+	// Anonymous performs requests that are not authenticated.
+	Anonymous = "anonymous"
+	// System performs changes made by the service itself, e.g. enrichment.
+	System = "system"
+)
+
+// From returns the subject of the principal of ctx, Anonymous when there is
+// none.
+func From(ctx context.Context) string {
+	if p, ok := principal.From(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+
+	return Anonymous
+}
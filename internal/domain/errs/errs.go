@@ -0,0 +1,120 @@
+// Package errs is the error model shared by all layers. An Error carries a
+// kind, which decides how it is reported to clients, and a stable code that
+// clients can match on instead of the message.
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindValidation      Kind = "validation"
+	KindUnauthenticated Kind = "unauthenticated"
+	KindForbidden       Kind = "forbidden"
+	KindNotFound        Kind = "not_found"
+	KindConflict        Kind = "conflict"
+	KindPrecondition    Kind = "precondition"
+	KindOutOfRange      Kind = "out_of_range"
+	KindTooLarge        Kind = "too_large"
+	KindRateLimited     Kind = "rate_limited"
+	KindUnsupported     Kind = "unsupported"
+	KindUpstream        Kind = "upstream"
+	KindUnavailable     Kind = "unavailable"
+	KindInternal        Kind = "internal"
+)
+
+// FieldError describes what is wrong with a single input field or parameter.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func Field(field string, message string) FieldError {
+	return FieldError{Field: field, Message: message}
+}
+
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Detail  string
+	Fields  []FieldError
+	cause   error
+}
+
+func New(kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+
+	if e.Detail != "" {
+		b.WriteString(": " + e.Detail)
+	} else if len(e.Fields) > 0 {
+		parts := make([]string, len(e.Fields))
+		for i, field := range e.Fields {
+			parts[i] = "'" + field.Field + "' " + field.Message
+		}
+		b.WriteString(": " + strings.Join(parts, ", "))
+	}
+
+	if e.cause != nil {
+		b.WriteString(": " + e.cause.Error())
+	}
+
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors by code, so an error derived from a sentinel with
+// Withf, WithFields or Wrap still matches the sentinel.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Withf returns a copy of the error with a description of this occurrence.
+func (e *Error) Withf(format string, args ...any) *Error {
+	c := *e
+	c.Detail = fmt.Sprintf(format, args...)
+	return &c
+}
+
+// WithFields returns a copy of the error with the given field errors added.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := *e
+	c.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &c
+}
+
+// Wrap returns a copy of the error caused by cause.
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// As returns the outermost Error in the chain of err.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf returns the kind of err, KindInternal for errors outside the model.
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+
+	return KindInternal
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// APIKey describes a stored API key. The key itself is never stored, Prefix
+// is its first characters to tell keys apart.
+type APIKey struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// APIKeyRequest names a new key. Roles are granted to the name, so keys
+// sharing a name share their roles.
+type APIKeyRequest struct {
+	Name  string   `json:"name" validate:"required,max=255"`
+	Roles []string `json:"roles" validate:"dive,required,max=64"`
+}
+
+// CreatedAPIKey is returned once, when the key is created. Key is the only
+// copy of the secret.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
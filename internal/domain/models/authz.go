@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Denial records an operation refused to a subject for lack of permission.
+type Denial struct {
+	ID         int64     `json:"id"`
+	Subject    string    `json:"subject"`
+	Permission string    `json:"permission"`
+	Operation  string    `json:"operation"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
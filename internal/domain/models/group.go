@@ -1,6 +1,27 @@
 package models
 
+import "time"
+
 type Group struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+type GroupRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type GroupResponse struct {
+	Group
+	SongsCount int64 `json:"songsCount"`
+}
+
+type GroupFilter struct {
+	Name           string
+	IncludeDeleted bool
+}
+
+type GroupMerge struct {
+	TargetID int64 `json:"targetId"`
 }
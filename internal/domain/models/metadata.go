@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	CacheTierMemory     = "memory"
+	CacheTierPersistent = "persistent"
+)
+
+// MetadataCacheEntry is a cached answer of the metadata providers. Found is
+// false for a cached "not found" answer, which has no detail.
+type MetadataCacheEntry struct {
+	Key       string      `json:"key"`
+	Group     string      `json:"group"`
+	Song      string      `json:"song"`
+	Found     bool        `json:"found"`
+	Detail    *SongDetail `json:"detail,omitempty"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+	Tier      string      `json:"tier,omitempty"`
+}
+
+// MetadataCachePurge counts the entries removed from each tier.
+type MetadataCachePurge struct {
+	Memory     int64 `json:"memory"`
+	Persistent int64 `json:"persistent"`
+}
+
+type MetadataCacheListing struct {
+	Memory     []MetadataCacheEntry `json:"memory"`
+	Persistent []MetadataCacheEntry `json:"persistent,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// SongChange describes a write to a song that is recorded as a revision.
+type SongChange struct {
+	Action       string
+	Actor        string
+	RestoredFrom int64
+}
+
+// SongRevision is an immutable snapshot of a song taken by a write. A delete
+// revision holds the song as it was before it was deleted.
+type SongRevision struct {
+	SongID       int64        `json:"songId"`
+	Revision     int64        `json:"revision"`
+	Action       string       `json:"action"`
+	Actor        string       `json:"actor"`
+	RestoredFrom *int64       `json:"restoredFrom,omitempty"`
+	Snapshot     SongDocument `json:"snapshot"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+const (
+	LineEqual  = "equal"
+	LineInsert = "insert"
+	LineDelete = "delete"
+)
+
+// LineChange is a line of a lyrics diff. FromLine and ToLine are 1-based
+// line numbers in the old and new lyrics, 0 when the line is not there.
+type LineChange struct {
+	Op       string `json:"op"`
+	Text     string `json:"text"`
+	FromLine int    `json:"fromLine,omitempty"`
+	ToLine   int    `json:"toLine,omitempty"`
+}
+
+// SongRevisionDiff compares two revisions: changed fields other than the
+// lyrics, and the lyrics line by line.
+type SongRevisionDiff struct {
+	SongID int64         `json:"songId"`
+	From   int64         `json:"from"`
+	To     int64         `json:"to"`
+	Fields []FieldChange `json:"fields"`
+	Lyrics []LineChange  `json:"lyrics"`
+}
@@ -3,29 +3,39 @@ package models
 import "time"
 
 type SongName struct {
-	Name string `json:"song"`
+	Name string `json:"song" validate:"required,max=255"`
 }
 
 type SongRequest struct {
-	Group string `json:"group"`
-	Name  string `json:"song"`
+	Group string `json:"group" validate:"required,max=255"`
+	Name  string `json:"song" validate:"required,max=255"`
 }
 
 type SongDetail struct {
 	ReleaseDate time.Time `json:"releaseDate"`
 	Text        string    `json:"text,omitempty"`
-	Link        string    `josn:"link,omitempty"`
+	Link        string    `json:"link,omitempty"`
 }
 
 type SongResponse struct {
 	ID int64 `json:"id"`
 	SongRequest
 	SongDetail
+	EnrichmentStatus string     `json:"enrichmentStatus,omitempty"`
+	Version          int64      `json:"version"`
+	DeletedAt        *time.Time `json:"deletedAt,omitempty"`
 }
 
 type SongFilter struct {
 	SongRequest
 	SongDetail
+	ReleaseDateFrom time.Time
+	ReleaseDateTo   time.Time
+	TextContains    string
+	HasLink         *bool
+	HasText         *bool
+	Sort            []SortKey
+	IncludeDeleted  bool
 }
 
 type SongStorage struct {
@@ -33,20 +43,138 @@ type SongStorage struct {
 	GroupID int64
 	Name    string
 	SongDetail
+	EnrichmentStatus string
+	// Version is incremented by every write. A non-zero version passed to
+	// an update or delete makes it conditional on the song still having it.
+	Version   int64
+	DeletedAt *time.Time
 }
 
 type Song struct {
 	ID int64 `json:"id"`
 	SongRequest
-	ReleaseDate string `json:"releaseDate"`
-	Text        string `json:"text,omitempty"`
-	Link        string `josn:"link,omitempty"`
+	ReleaseDate string `json:"releaseDate" validate:"required,date,notfuture"`
+	Text        string `json:"text,omitempty" validate:"max=65536"`
+	Link        string `json:"link,omitempty" validate:"max=2048,url"`
 }
 
 type SongUpdate struct {
-	Name        string `json:"song"`
-	Group       string `json:"group"`
-	ReleaseDate string `json:"releaseDate"`
-	Text        string `json:"text,omitempty"`
-	Link        string `josn:"link,omitempty"`
+	Name        string `json:"song" validate:"required,max=255"`
+	Group       string `json:"group" validate:"required,max=255"`
+	ReleaseDate string `json:"releaseDate" validate:"required,date,notfuture"`
+	Text        string `json:"text,omitempty" validate:"max=65536"`
+	Link        string `json:"link,omitempty" validate:"max=2048,url"`
+}
+
+// SongDocument is the JSON document of a stored song that PATCH requests
+// are applied to. Release date stays empty while the song is not enriched.
+type SongDocument struct {
+	Name        string `json:"song" validate:"required,max=255"`
+	Group       string `json:"group" validate:"required,max=255"`
+	ReleaseDate string `json:"releaseDate" validate:"date,notfuture"`
+	Text        string `json:"text" validate:"max=65536"`
+	Link        string `json:"link" validate:"max=2048,url"`
+}
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+type SongCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+	ID     int64    `json:"id"`
+}
+
+type Pagination struct {
+	Cursor    string
+	Limit     int
+	WithTotal bool
+}
+
+type SongPage struct {
+	Songs      []SongResponse `json:"songs"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
+	Total      *int64         `json:"total,omitempty"`
+}
+
+type SongSearch struct {
+	Query    string
+	Language string
+	Offset   int
+	Limit    int
+}
+
+type SongSearchHit struct {
+	ID          int64     `json:"id"`
+	Group       string    `json:"group"`
+	Name        string    `json:"song"`
+	ReleaseDate time.Time `json:"releaseDate"`
+	Link        string    `json:"link,omitempty"`
+	Rank        float32   `json:"rank"`
+	Verse       *int      `json:"verse,omitempty"`
+	Snippet     string    `json:"snippet"`
+}
+
+type VerseRange struct {
+	Offset int
+	Limit  int
+}
+
+type Verse struct {
+	Index int      `json:"index"`
+	Lines []string `json:"lines"`
+}
+
+type SongText struct {
+	SongID      int64   `json:"songId"`
+	Verses      []Verse `json:"verses"`
+	TotalVerses int     `json:"total_verses"`
+	HasMore     bool    `json:"has_more"`
+}
+
+const (
+	SectionVerse  = "verse"
+	SectionChorus = "chorus"
+	SectionBridge = "bridge"
+	SectionIntro  = "intro"
+	SectionOutro  = "outro"
+)
+
+type LyricsLine struct {
+	Text    string `json:"text"`
+	StartMs *int64 `json:"startMs,omitempty"`
+}
+
+type LyricsSection struct {
+	Type  string       `json:"type"`
+	Lines []LyricsLine `json:"lines"`
+}
+
+type Lyrics struct {
+	SongID   int64           `json:"songId"`
+	Sections []LyricsSection `json:"sections"`
+}
+
+const (
+	EnrichmentPending  = "pending"
+	EnrichmentEnriched = "enriched"
+	EnrichmentFailed   = "failed"
+)
+
+type EnrichmentJob struct {
+	ID       int64
+	SongID   int64
+	Attempts int
+}
+
+type Enrichment struct {
+	SongID    int64      `json:"songId"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"lastError,omitempty"`
+	NextRunAt *time.Time `json:"nextRunAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
 }
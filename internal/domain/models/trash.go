@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	TrashSong  = "song"
+	TrashGroup = "group"
+)
+
+// TrashItem is a deleted song or group waiting to be purged. Group is the
+// group name of a song.
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Group     string    `json:"group,omitempty"`
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+// PurgeResult counts the items removed from the trash for good.
+type PurgeResult struct {
+	Songs  int64 `json:"songs"`
+	Groups int64 `json:"groups"`
+}
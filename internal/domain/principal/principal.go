@@ -0,0 +1,92 @@
+// Package principal carries the authenticated caller of a request through a
+// context.
+package principal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Built-in roles. Their permissions can be changed in the config.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Principal is who a request was authenticated as. KeyID is set for callers
+// authenticated with a stored API key. Roles decide what the caller may do.
+type Principal struct {
+	Subject string   `json:"subject"`
+	Method  string   `json:"method"`
+	KeyID   int64    `json:"keyId,omitempty"`
+	Roles   []string `json:"roles"`
+}
+
+type ctxKey struct{}
+
+// With returns a copy of ctx authenticated as p.
+func With(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// Prefixes of the subjects roles are granted to. API keys are granted by ID,
+// their names are not unique; tokens by their sub claim.
+const (
+	grantKeyPrefix   = "key:"
+	grantTokenPrefix = "jwt:"
+)
+
+// KeyGrantSubject is the subject roles are granted to for the API key id.
+func KeyGrantSubject(id int64) string {
+	return grantKeyPrefix + strconv.FormatInt(id, 10)
+}
+
+// TokenGrantSubject is the subject roles are granted to for tokens with the
+// sub claim.
+func TokenGrantSubject(sub string) string {
+	return grantTokenPrefix + sub
+}
+
+// ValidGrantSubject reports whether subject names an API key or a token
+// subject.
+func ValidGrantSubject(subject string) bool {
+	if id, ok := strings.CutPrefix(subject, grantKeyPrefix); ok {
+		_, err := strconv.ParseInt(id, 10, 64)
+		return err == nil
+	}
+
+	sub, ok := strings.CutPrefix(subject, grantTokenPrefix)
+	return ok && sub != ""
+}
+
+// GrantSubject is the subject the roles of p are granted to.
+func (p Principal) GrantSubject() string {
+	if p.Method == MethodAPIKey && p.KeyID != 0 {
+		return KeyGrantSubject(p.KeyID)
+	}
+	return TokenGrantSubject(p.Subject)
+}
+
+// HasRole reports whether p was granted role.
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// From returns the principal of ctx, false for unauthenticated contexts.
+func From(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
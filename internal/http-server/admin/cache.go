@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+	"strconv"
+)
+
+// ListMetadataCache lists the cached answers of the metadata providers.
+// @Summary List metadata cache
+// @Description List all live in-memory entries and a page of the persistent (Postgres) tier
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number of the persistent tier"
+// @Success 200 {object} httpserver.Response{data=models.MetadataCacheListing}
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/metadata-cache [get]
+func (s *Server) ListMetadataCache(w http.ResponseWriter, r *http.Request) {
+	page := 0
+	if pageParam := r.URL.Query().Get("page"); pageParam != "" {
+		if parsedPage, err := strconv.Atoi(pageParam); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	listing, err := s.cache.List(r.Context(), s.pageSize*page, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched metadata cache", http.StatusOK, listing)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// InspectMetadataCache shows the cached entry of a song.
+// @Summary Inspect metadata cache entry
+// @Description Get the cached provider answer for a group and song without calling the providers
+// @Tags admin
+// @Produce json
+// @Param group query string true "Group name"
+// @Param song query string true "Song name"
+// @Success 200 {object} httpserver.Response{data=models.MetadataCacheEntry}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/metadata-cache/entry [get]
+func (s *Server) InspectMetadataCache(w http.ResponseWriter, r *http.Request) {
+	songReq, ok := readSongRequest(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := s.cache.Inspect(r.Context(), songReq)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched metadata cache entry", http.StatusOK, entry)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// EvictMetadataCache removes the cached entry of a song.
+// @Summary Evict metadata cache entry
+// @Description Remove the cached provider answer for a group and song from the memory tier of the serving replica and from the persistent tier. Other replicas keep their copy until it expires
+// @Tags admin
+// @Produce json
+// @Param group query string true "Group name"
+// @Param song query string true "Song name"
+// @Success 200 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/metadata-cache/entry [delete]
+func (s *Server) EvictMetadataCache(w http.ResponseWriter, r *http.Request) {
+	songReq, ok := readSongRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.cache.Evict(r.Context(), songReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Cache entry was evicted", http.StatusOK, nil)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// PurgeMetadataCache empties the metadata cache.
+// @Summary Purge metadata cache
+// @Description Remove all entries from the memory tier of the serving replica and from the persistent tier. Returns the number of removed entries per tier. Other replicas keep their memory tier until the entries expire
+// @Tags admin
+// @Produce json
+// @Success 200 {object} httpserver.Response{data=models.MetadataCachePurge}
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/metadata-cache [delete]
+func (s *Server) PurgeMetadataCache(w http.ResponseWriter, r *http.Request) {
+	purge, err := s.cache.Purge(r.Context())
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Metadata cache was purged", http.StatusOK, purge)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+func readSongRequest(w http.ResponseWriter, r *http.Request) (models.SongRequest, bool) {
+	params := r.URL.Query()
+	songReq := models.SongRequest{Group: params.Get("group"), Name: params.Get("song")}
+
+	if err := srv.ValidateSongRequest(&songReq); err != nil {
+		srv.WriteError(w, r, err)
+		return models.SongRequest{}, false
+	}
+
+	return songReq, true
+}
@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"log/slog"
+	"net/http"
+)
+
+type MetadataCache interface {
+	Inspect(ctx context.Context, songReq models.SongRequest) (models.MetadataCacheEntry, error)
+	List(ctx context.Context, offset int, limit int) (models.MetadataCacheListing, error)
+	Evict(ctx context.Context, songReq models.SongRequest) error
+	Purge(ctx context.Context) (models.MetadataCachePurge, error)
+}
+
+type Server struct {
+	log      *slog.Logger
+	pageSize int
+	cache    MetadataCache
+}
+
+func New(log *slog.Logger, pageSize int, cache MetadataCache) *Server {
+	return &Server{
+		log:      log,
+		pageSize: pageSize,
+		cache:    cache,
+	}
+}
+
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/metadata-cache", s.ListMetadataCache)
+	mux.HandleFunc("DELETE /admin/metadata-cache", s.PurgeMetadataCache)
+	mux.HandleFunc("GET /admin/metadata-cache/entry", s.InspectMetadataCache)
+	mux.HandleFunc("DELETE /admin/metadata-cache/entry", s.EvictMetadataCache)
+}
@@ -0,0 +1,106 @@
+package apikey
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+	"strconv"
+)
+
+// CreateAPIKey issues a new API key.
+// @Summary Create API key
+// @Description Create an API key for a client. The key is returned only in this response,
+// @Description only its hash is stored. Send it in the X-API-Key header. Roles are granted to the key name
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyRequest true "API key name and roles"
+// @Success 201 {object} httpserver.Response{data=models.CreatedAPIKey}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 401 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/api-keys [post]
+func (s *Server) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var keyReq models.APIKeyRequest
+
+	if err := srv.ReadJSON(r, &keyReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	if err := srv.Validate(&keyReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	key, err := s.service.CreateAPIKey(r.Context(), keyReq.Name, keyReq.Roles)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Created API key", http.StatusCreated, key)
+
+	jsn.WriteResponseBody(w, resp, http.StatusCreated)
+}
+
+// ListAPIKeys lists the stored API keys.
+// @Summary List API keys
+// @Description List API keys, including revoked ones. Secrets are never returned
+// @Tags auth
+// @Produce json
+// @Param page query int false "Page number"
+// @Success 200 {object} httpserver.Response{data=[]models.APIKey}
+// @Failure 401 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/api-keys [get]
+func (s *Server) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	page := 0
+	if pageParam := r.URL.Query().Get("page"); pageParam != "" {
+		if parsedPage, err := strconv.Atoi(pageParam); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	keys, err := s.service.ListAPIKeys(r.Context(), s.pageSize*page, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched API keys", http.StatusOK, keys)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// RevokeAPIKey revokes an API key.
+// @Summary Revoke API key
+// @Description Revoke an API key by its ID. Requests with the key are rejected from now on
+// @Tags auth
+// @Param id path int true "API key ID"
+// @Success 204 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 401 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/api-keys/{id} [delete]
+func (s *Server) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 3)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	if err := s.service.RevokeAPIKey(r.Context(), id); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("API key was revoked", http.StatusNoContent, nil)
+
+	jsn.WriteResponseBody(w, resp, http.StatusNoContent)
+}
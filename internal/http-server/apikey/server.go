@@ -0,0 +1,34 @@
+package apikey
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"log/slog"
+	"net/http"
+)
+
+type Service interface {
+	CreateAPIKey(ctx context.Context, name string, roles []string) (models.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, offset int, limit int) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+type Server struct {
+	log      *slog.Logger
+	pageSize int
+	service  Service
+}
+
+func New(log *slog.Logger, pageSize int, service Service) *Server {
+	return &Server{
+		log:      log,
+		pageSize: pageSize,
+		service:  service,
+	}
+}
+
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /admin/api-keys", s.CreateAPIKey)
+	mux.HandleFunc("GET /admin/api-keys", s.ListAPIKeys)
+	mux.HandleFunc("DELETE /admin/api-keys/{id}", s.RevokeAPIKey)
+}
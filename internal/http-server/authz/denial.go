@@ -0,0 +1,39 @@
+package authz
+
+import (
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+	"strconv"
+)
+
+// ListDenials lists the operations refused for lack of permission.
+// @Summary List authorization denials
+// @Description Get the operations callers were refused because none of their roles grants the
+// @Description needed permission, newest first
+// @Tags auth
+// @Produce json
+// @Param page query int false "Page number"
+// @Success 200 {object} httpserver.Response{data=[]models.Denial}
+// @Failure 401 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /admin/authz/denials [get]
+func (s *Server) ListDenials(w http.ResponseWriter, r *http.Request) {
+	page := 0
+	if pageParam := r.URL.Query().Get("page"); pageParam != "" {
+		if parsedPage, err := strconv.Atoi(pageParam); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	denials, err := s.service.ListDenials(r.Context(), s.pageSize*page, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched denials", http.StatusOK, denials)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
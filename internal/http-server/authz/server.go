@@ -0,0 +1,30 @@
+package authz
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"log/slog"
+	"net/http"
+)
+
+type Service interface {
+	ListDenials(ctx context.Context, offset int, limit int) ([]models.Denial, error)
+}
+
+type Server struct {
+	log      *slog.Logger
+	pageSize int
+	service  Service
+}
+
+func New(log *slog.Logger, pageSize int, service Service) *Server {
+	return &Server{
+		log:      log,
+		pageSize: pageSize,
+		service:  service,
+	}
+}
+
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/authz/denials", s.ListDenials)
+}
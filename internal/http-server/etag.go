@@ -0,0 +1,52 @@
+package httpserver
+
+import (
+	"effectivemobiletesttask/internal/domain/errs"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrPreconditionFailed = errs.New(errs.KindPrecondition, "precondition_failed", "If-Match does not match the current version")
+
+// ETag formats a resource version as a strong entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch checks the If-Match header against the current version. It returns
+// the version a write has to be conditional on, 0 when the header is absent
+// or "*".
+func IfMatch(r *http.Request, current int64) (int64, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return 0, nil
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		// Weak tags never match: If-Match uses the strong comparison.
+		if strings.TrimSpace(tag) == ETag(current) {
+			return current, nil
+		}
+	}
+
+	return 0, ErrPreconditionFailed.Withf("current ETag is %s", ETag(current))
+}
+
+// NotModified reports whether the If-None-Match header matches the current
+// version, in which case a GET should be answered with 304.
+func NotModified(r *http.Request, current int64) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == ETag(current) {
+			return true
+		}
+	}
+
+	return false
+}
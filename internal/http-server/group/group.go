@@ -0,0 +1,290 @@
+package group
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+	"strconv"
+)
+
+// CreateGroup adds a new group to the library.
+// @Summary Add a new group
+// @Description Add a new group (artist) to the music library
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param group body models.GroupRequest true "Group Request"
+// @Success 201 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 409 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /group/create [post]
+func (s *Server) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var groupReq models.GroupRequest
+
+	if err := srv.ReadJSON(r, &groupReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	defer r.Body.Close()
+
+	if err := srv.Validate(&groupReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	id, err := s.service.CreateGroup(r.Context(), groupReq.Name)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Added new group", http.StatusCreated, id)
+
+	jsn.WriteResponseBody(w, resp, http.StatusCreated)
+}
+
+// GetGroupByID retrieves a group by its ID.
+// @Summary Get group by ID
+// @Description Get a specific group by its unique ID together with its songs count
+// @Tags groups
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param include_deleted query bool false "Return the group even if it is in the trash"
+// @Success 200 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /group/{id} [get]
+func (s *Server) GetGroupByID(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	includeDeleted, err := srv.ParseIncludeDeleted(r.URL.Query())
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	group, err := s.service.GetGroupByID(r.Context(), id, includeDeleted)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched group", http.StatusOK, group)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// GetAllGroups retrieves groups matching specific filters.
+// @Summary Get all groups
+// @Description Fetch groups whose name contains the provided value
+// @Tags groups
+// @Produce json
+// @Param name query string false "Group name (case-insensitive substring)"
+// @Param page query int false "Page number"
+// @Param include_deleted query bool false "Include groups in the trash"
+// @Success 200 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /group/all [get]
+func (s *Server) GetAllGroups(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	var filter models.GroupFilter
+	filter.Name = params.Get("name")
+
+	includeDeleted, err := srv.ParseIncludeDeleted(params)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+	filter.IncludeDeleted = includeDeleted
+
+	pageParam := params.Get("page")
+	page := 0
+
+	if pageParam != "" {
+		if parsedPage, err := strconv.Atoi(pageParam); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	groups, err := s.service.GetAllGroups(r.Context(), filter, s.pageSize*page, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched groups", http.StatusOK, groups)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// RenameGroup changes the name of an existing group.
+// @Summary Rename group
+// @Description Rename a group by its ID
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param group body models.GroupRequest true "New Group Name"
+// @Success 200 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 409 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /group/{id} [put]
+// @Router /group/{id} [patch]
+func (s *Server) RenameGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	var groupReq models.GroupRequest
+
+	if err := srv.ReadJSON(r, &groupReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	if err := srv.Validate(&groupReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	group, err := s.service.RenameGroup(r.Context(), id, groupReq.Name)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully renamed group", http.StatusOK, group)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// MergeGroups moves all songs of a group into another group and removes it.
+// @Summary Merge groups
+// @Description Move every song of the group into the target group and move the source group to the trash
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Source Group ID"
+// @Param merge body models.GroupMerge true "Target Group"
+// @Success 200 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /group/{id}/merge [post]
+func (s *Server) MergeGroups(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	var merge models.GroupMerge
+
+	if err := srv.ReadJSON(r, &merge); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	if merge.TargetID == 0 {
+		srv.WriteError(w, r, srv.RequiredField("targetId"))
+		return
+	}
+
+	group, err := s.service.MergeGroups(r.Context(), id, merge.TargetID)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully merged groups", http.StatusOK, group)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// DeleteGroup moves a group to the trash.
+// @Summary Delete group
+// @Description Move a group to the trash by its ID. Without cascade the group must have no songs
+// @Tags groups
+// @Param id path int true "Group ID"
+// @Param cascade query bool false "Move the group's songs to the trash as well"
+// @Success 204 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 409 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /group/{id} [delete]
+func (s *Server) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	cascade, err := srv.ParseBoolParam(r.URL.Query(), "cascade")
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	err = s.service.DeleteGroup(r.Context(), id, cascade)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully deleted group", http.StatusNoContent, nil)
+
+	jsn.WriteResponseBody(w, resp, http.StatusNoContent)
+}
+
+// RestoreGroup takes a group out of the trash.
+// @Summary Restore group from trash
+// @Description Restore a deleted group together with the songs that were deleted with it (cascade).
+// @Description Fails with 409 when a live group has taken its name in the meantime
+// @Tags trash
+// @Produce json
+// @Param id path int true "Group ID"
+// @Success 200 {object} httpserver.Response{data=models.GroupResponse}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 409 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /group/{id}/restore [post]
+func (s *Server) RestoreGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	group, err := s.service.RestoreGroup(r.Context(), id)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully restored group", http.StatusOK, group)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
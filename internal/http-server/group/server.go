@@ -0,0 +1,43 @@
+package group
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"log/slog"
+	"net/http"
+)
+
+type Service interface {
+	CreateGroup(ctx context.Context, groupName string) (int64, error)
+	GetGroupByID(ctx context.Context, id int64, includeDeleted bool) (models.GroupResponse, error)
+	GetAllGroups(ctx context.Context, filter models.GroupFilter, offset int, limit int) ([]models.GroupResponse, error)
+	RenameGroup(ctx context.Context, id int64, groupName string) (models.GroupResponse, error)
+	MergeGroups(ctx context.Context, sourceID int64, targetID int64) (models.GroupResponse, error)
+	DeleteGroup(ctx context.Context, id int64, cascade bool) error
+	RestoreGroup(ctx context.Context, id int64) (models.GroupResponse, error)
+}
+
+type Server struct {
+	log      *slog.Logger
+	pageSize int
+	service  Service
+}
+
+func New(log *slog.Logger, pageSize int, service Service) *Server {
+	return &Server{
+		log:      log,
+		pageSize: pageSize,
+		service:  service,
+	}
+}
+
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /group/create", s.CreateGroup)
+	mux.HandleFunc("GET /group/all", s.GetAllGroups)
+	mux.HandleFunc("GET /group/{id}", s.GetGroupByID)
+	mux.HandleFunc("PATCH /group/{id}", s.RenameGroup)
+	mux.HandleFunc("PUT /group/{id}", s.RenameGroup)
+	mux.HandleFunc("POST /group/{id}/merge", s.MergeGroups)
+	mux.HandleFunc("DELETE /group/{id}", s.DeleteGroup)
+	mux.HandleFunc("POST /group/{id}/restore", s.RestoreGroup)
+}
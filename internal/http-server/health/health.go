@@ -0,0 +1,47 @@
+package health
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+)
+
+// Live reports that the process is alive.
+// @Summary Liveness probe
+// @Description Report that the process is up. No dependency is checked, no authentication is required
+// @Tags health
+// @Produce json
+// @Success 200 {object} httpserver.Response{data=models.Health}
+// @Security
+// @Router /healthz [get]
+func (s *Server) Live(w http.ResponseWriter, r *http.Request) {
+	resp := srv.NewResponse("Service is alive", http.StatusOK, s.service.Live())
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// Ready reports whether the service can serve requests.
+// @Summary Readiness probe
+// @Description Check PostgreSQL, the migration version and the metadata providers, with the latency of each
+// @Description check. The service is not ready while a critical check fails and degraded while only
+// @Description non-critical ones fail. No authentication is required
+// @Tags health
+// @Produce json
+// @Success 200 {object} httpserver.Response{data=models.Health}
+// @Failure 503 {object} httpserver.Response{data=models.Health}
+// @Security
+// @Router /readyz [get]
+func (s *Server) Ready(w http.ResponseWriter, r *http.Request) {
+	health := s.service.Ready(r.Context())
+
+	if health.Status == models.HealthDown {
+		resp := srv.NewResponse("Service is not ready", http.StatusServiceUnavailable, health)
+		jsn.WriteResponseBody(w, resp, http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := srv.NewResponse("Service is ready", http.StatusOK, health)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
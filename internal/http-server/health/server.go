@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"log/slog"
+	"net/http"
+)
+
+type Service interface {
+	Live() models.Health
+	Ready(ctx context.Context) models.Health
+}
+
+type Server struct {
+	log     *slog.Logger
+	service Service
+}
+
+func New(log *slog.Logger, service Service) *Server {
+	return &Server{
+		log:     log,
+		service: service,
+	}
+}
+
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", s.Live)
+	mux.HandleFunc("GET /readyz", s.Ready)
+}
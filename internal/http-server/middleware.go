@@ -0,0 +1,71 @@
+package httpserver
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/errs"
+	"effectivemobiletesttask/internal/domain/principal"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries the API key of the caller.
+const APIKeyHeader = "X-API-Key"
+
+type Authenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (principal.Principal, error)
+	AuthenticateToken(ctx context.Context, token string) (principal.Principal, error)
+}
+
+// Authenticate requires requests outside the public path prefixes to carry an
+// API key in the X-API-Key header or a bearer token in the Authorization
+// header, and puts the authenticated principal into the request context.
+func Authenticate(auth Authenticator, publicPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range publicPaths {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			p, err := authenticate(auth, r)
+			if err != nil {
+				if errs.KindOf(err) == errs.KindUnauthenticated {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="song-lib"`)
+				}
+
+				WriteError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(principal.With(r.Context(), p)))
+		})
+	}
+}
+
+// hasCredentials reports whether the request carries an API key or an
+// Authorization header, valid or not.
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get(APIKeyHeader) != "" || r.Header.Get("Authorization") != ""
+}
+
+func authenticate(auth Authenticator, r *http.Request) (principal.Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return auth.AuthenticateAPIKey(r.Context(), key)
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return principal.Principal{}, ErrUnauthenticated
+	}
+
+	scheme, token, ok := strings.Cut(authorization, " ")
+	token = strings.TrimSpace(token)
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return principal.Principal{}, ErrUnauthenticated.Withf("Authorization header must use the Bearer scheme")
+	}
+
+	return auth.AuthenticateToken(r.Context(), token)
+}
@@ -0,0 +1,108 @@
+package httpserver
+
+import (
+	"bytes"
+	"effectivemobiletesttask/internal/domain/errs"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/utils/jsonpatch"
+	"encoding/json"
+	"mime"
+	"time"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// AcceptPatch lists the patch formats for the Accept-Patch header.
+const AcceptPatch = MergePatchContentType + ", " + JSONPatchContentType
+
+var ErrUnsupportedPatch = errs.New(errs.KindUnsupported, "unsupported_patch_format", "Unsupported patch format")
+
+// PatchSong applies patch to the current song and returns the validated
+// result. The format is chosen by contentType: JSON Patch for
+// application/json-patch+json, JSON Merge Patch for
+// application/merge-patch+json and plain application/json.
+func PatchSong(current models.SongResponse, contentType string, patch []byte) (models.SongDocument, error) {
+	apply, err := patchFunc(contentType)
+	if err != nil {
+		return models.SongDocument{}, err
+	}
+
+	doc, err := json.Marshal(SongResponseToDocument(current))
+	if err != nil {
+		return models.SongDocument{}, err
+	}
+
+	patched, err := apply(doc, patch)
+	if err != nil {
+		return models.SongDocument{}, err
+	}
+
+	var song models.SongDocument
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&song); err != nil {
+		return models.SongDocument{}, jsonpatch.ErrInvalidPatch.Withf("patched song: %s", err)
+	}
+
+	if err := Validate(&song); err != nil {
+		return models.SongDocument{}, err
+	}
+
+	return song, nil
+}
+
+func patchFunc(contentType string) (func(doc []byte, patch []byte) ([]byte, error), error) {
+	mediaType := MergePatchContentType
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, ErrUnsupportedPatch.Withf("%s", err)
+		}
+		mediaType = parsed
+	}
+
+	switch mediaType {
+	case MergePatchContentType, "application/json":
+		return jsonpatch.MergePatch, nil
+	case JSONPatchContentType:
+		return jsonpatch.Apply, nil
+	default:
+		return nil, ErrUnsupportedPatch.Withf("%q, use %s", mediaType, AcceptPatch)
+	}
+}
+
+func SongResponseToDocument(song models.SongResponse) models.SongDocument {
+	doc := models.SongDocument{
+		Name:  song.Name,
+		Group: song.Group,
+		Text:  song.Text,
+		Link:  song.Link,
+	}
+
+	if !song.ReleaseDate.IsZero() {
+		doc.ReleaseDate = song.ReleaseDate.Format(time.DateOnly)
+	}
+
+	return doc
+}
+
+// SongDocumentToSongResponse converts a validated document.
+func SongDocumentToSongResponse(id int64, doc models.SongDocument) models.SongResponse {
+	var songResp models.SongResponse
+
+	songResp.ID = id
+	songResp.Group = doc.Group
+	songResp.Name = doc.Name
+	songResp.Text = doc.Text
+	songResp.Link = doc.Link
+
+	if doc.ReleaseDate != "" {
+		songResp.ReleaseDate, _ = time.Parse(time.DateOnly, doc.ReleaseDate)
+	}
+
+	return songResp
+}
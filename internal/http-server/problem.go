@@ -0,0 +1,81 @@
+package httpserver
+
+import (
+	"effectivemobiletesttask/internal/domain/errs"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Type is derived from the
+// stable error code, Code repeats it for clients that do not parse URIs.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	Errors   []errs.FieldError `json:"errors,omitempty"`
+}
+
+var kindStatus = map[errs.Kind]int{
+	errs.KindValidation:      http.StatusBadRequest,
+	errs.KindUnauthenticated: http.StatusUnauthorized,
+	errs.KindForbidden:       http.StatusForbidden,
+	errs.KindNotFound:        http.StatusNotFound,
+	errs.KindConflict:        http.StatusConflict,
+	errs.KindPrecondition:    http.StatusPreconditionFailed,
+	errs.KindOutOfRange:      http.StatusRequestedRangeNotSatisfiable,
+	errs.KindTooLarge:        http.StatusRequestEntityTooLarge,
+	errs.KindRateLimited:     http.StatusTooManyRequests,
+	errs.KindUnsupported:     http.StatusUnsupportedMediaType,
+	errs.KindUpstream:        http.StatusBadGateway,
+	errs.KindUnavailable:     http.StatusServiceUnavailable,
+	errs.KindInternal:        http.StatusInternalServerError,
+}
+
+// NewProblem maps err to a problem. Errors outside the domain error model
+// are reported as internal errors without exposing their message.
+func NewProblem(err error, instance string) Problem {
+	e, ok := errs.As(err)
+	if !ok {
+		e = ErrInternalServer
+	}
+
+	status, ok := kindStatus[e.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	problem := Problem{
+		Type:     "/problems/" + strings.ReplaceAll(e.Code, "_", "-"),
+		Title:    e.Message,
+		Status:   status,
+		Detail:   e.Detail,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+
+	if status == http.StatusInternalServerError {
+		problem.Detail = ""
+		problem.Errors = nil
+	}
+
+	return problem
+}
+
+// WriteError writes err as an application/problem+json response.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := NewProblem(err, r.URL.Path)
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,173 @@
+package httpserver
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/principal"
+	"effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/ratelimit"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Route classes with separate rate limits.
+const (
+	RateLimitRead       = "read"
+	RateLimitWrite      = "write"
+	RateLimitEnrichment = "enrichment"
+)
+
+type RateLimiter interface {
+	Allow(ctx context.Context, class string, client string) (res ratelimit.Result, ok bool, err error)
+	Check(ctx context.Context, class string, client string) (res ratelimit.Result, ok bool, err error)
+}
+
+// rateLimitedKey marks in the request context that the request has taken a
+// token, so that the pass in front of authentication does not charge it too.
+type rateLimitedKey struct{}
+
+// RateLimit refuses requests of clients that used up the bucket of the route
+// class returned by classify, "" for requests that are not limited. identify
+// names the client, requests it cannot name are not limited. Clients are
+// told their quota in the RateLimit-* headers. When the limiter fails the
+// request is let through.
+func RateLimit(
+	log *slog.Logger,
+	limiter RateLimiter,
+	classify func(r *http.Request) string,
+	identify func(r *http.Request) (string, bool),
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := classify(r)
+			if class == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client, ok := identify(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if charged, ok := r.Context().Value(rateLimitedKey{}).(*bool); ok {
+				*charged = true
+			}
+
+			res, ok, err := limiter.Allow(r.Context(), class, client)
+			if !limited(log, w, r, class, res, ok, err) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RateLimitUnauthenticated goes in front of authentication and limits by IP
+// the requests that do not get authenticated. Requests without credentials
+// take a token right away. Requests with credentials take one only when
+// authentication fails, and are refused before their credentials are
+// checked once the failures of their IP used up the bucket. Authenticated
+// requests take their token in the RateLimit pass behind authentication
+// alone, so that clients sharing an IP do not share a quota.
+func RateLimitUnauthenticated(
+	log *slog.Logger,
+	limiter RateLimiter,
+	classify func(r *http.Request) string,
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := classify(r)
+			if class == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client, _ := ClientIP(r)
+
+			if !hasCredentials(r) {
+				res, ok, err := limiter.Allow(r.Context(), class, client)
+				if !limited(log, w, r, class, res, ok, err) {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+
+			res, ok, err := limiter.Check(r.Context(), class, client)
+			if err == nil && ok && !res.Allowed {
+				limited(log, w, r, class, res, ok, err)
+				return
+			}
+
+			var charged bool
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rateLimitedKey{}, &charged)))
+
+			if !charged {
+				if _, _, err := limiter.Allow(r.Context(), class, client); err != nil {
+					log.ErrorContext(r.Context(), "error checking rate limit", logger.Err(err))
+				}
+			}
+		})
+	}
+}
+
+// limited sets the RateLimit-* headers of res and reports whether the
+// request was refused, in which case the response has been written.
+func limited(log *slog.Logger, w http.ResponseWriter, r *http.Request, class string, res ratelimit.Result, ok bool, err error) bool {
+	if err != nil {
+		log.ErrorContext(r.Context(), "error checking rate limit", logger.Err(err))
+		return false
+	}
+
+	if !ok {
+		return false
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+
+	if res.Allowed {
+		return false
+	}
+
+	retryAfter := max(seconds(res.RetryAfter), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+	log.WarnContext(r.Context(), "rate limit exceeded", slog.String("class", class))
+	WriteError(w, r, ErrRateLimited.Withf("%s limit exceeded, retry in %d s", class, retryAfter))
+	return true
+}
+
+// ClientIP identifies the caller by remote address, for requests that are
+// not authenticated.
+func ClientIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host, true
+}
+
+// ClientPrincipal identifies an authenticated caller: by API key, by subject
+// for bearer tokens. Unauthenticated requests are left to
+// RateLimitUnauthenticated.
+func ClientPrincipal(r *http.Request) (string, bool) {
+	p, ok := principal.From(r.Context())
+	if !ok {
+		return "", false
+	}
+
+	if p.KeyID != 0 {
+		return "key:" + strconv.FormatInt(p.KeyID, 10), true
+	}
+	return "sub:" + p.Subject, true
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
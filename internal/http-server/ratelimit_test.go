@@ -0,0 +1,158 @@
+package httpserver
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/principal"
+	"effectivemobiletesttask/internal/utils/ratelimit"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// countingLimiter records the clients charged a token.
+type countingLimiter struct {
+	*ratelimit.Limiter
+	charged []string
+}
+
+func (l *countingLimiter) Allow(ctx context.Context, class string, client string) (ratelimit.Result, bool, error) {
+	l.charged = append(l.charged, client)
+	return l.Limiter.Allow(ctx, class, client)
+}
+
+// keyAuth accepts the API keys it maps to key IDs.
+type keyAuth struct {
+	keys    map[string]int64
+	lookups int
+}
+
+func (a *keyAuth) AuthenticateAPIKey(_ context.Context, key string) (principal.Principal, error) {
+	a.lookups++
+
+	id, ok := a.keys[key]
+	if !ok {
+		return principal.Principal{}, ErrUnauthenticated
+	}
+	return principal.Principal{Subject: key, Method: principal.MethodAPIKey, KeyID: id}, nil
+}
+
+func (a *keyAuth) AuthenticateToken(context.Context, string) (principal.Principal, error) {
+	a.lookups++
+	return principal.Principal{}, ErrUnauthenticated
+}
+
+// newRateLimited builds the chain of the HTTP app: the IP pass in front of
+// authentication and the principal pass behind it, with a burst of 2.
+func newRateLimited(auth Authenticator) (http.Handler, *countingLimiter) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limiter := &countingLimiter{Limiter: ratelimit.New(log, ratelimit.NewMemoryStore(), map[string]ratelimit.Bucket{
+		RateLimitRead: ratelimit.NewBucket(1, time.Hour, 2),
+	})}
+	classify := func(*http.Request) string { return RateLimitRead }
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler = RateLimit(log, limiter, classify, ClientPrincipal)(handler)
+	handler = Authenticate(auth)(handler)
+	handler = RateLimitUnauthenticated(log, limiter, classify)(handler)
+
+	return handler, limiter
+}
+
+func get(handler http.Handler, apiKey string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	if apiKey != "" {
+		r.Header.Set(APIKeyHeader, apiKey)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRateLimitChargesOnce(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		wantStatus  []int
+		wantCharged []string
+		wantLookups int
+	}{
+		{
+			name:        "authenticated client is charged to its key only",
+			keys:        []string{"alpha", "alpha", "alpha"},
+			wantStatus:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			wantCharged: []string{"key:1", "key:1", "key:1"},
+			wantLookups: 3,
+		},
+		{
+			name:        "keys behind one IP have their own quotas",
+			keys:        []string{"alpha", "alpha", "beta", "beta"},
+			wantStatus:  []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK},
+			wantCharged: []string{"key:1", "key:1", "key:2", "key:2"},
+			wantLookups: 4,
+		},
+		{
+			name:        "anonymous requests are charged to the IP",
+			keys:        []string{"", "", ""},
+			wantStatus:  []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests},
+			wantCharged: []string{"ip:203.0.113.7", "ip:203.0.113.7", "ip:203.0.113.7"},
+			wantLookups: 0,
+		},
+		{
+			name:        "failed authentications are charged to the IP and then refused unchecked",
+			keys:        []string{"guess1", "guess2", "guess3", "alpha"},
+			wantStatus:  []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			wantCharged: []string{"ip:203.0.113.7", "ip:203.0.113.7"},
+			wantLookups: 2,
+		},
+		{
+			name:        "an authenticated client does not use up the IP quota",
+			keys:        []string{"alpha", "alpha", "guess1", "guess2"},
+			wantStatus:  []int{http.StatusOK, http.StatusOK, http.StatusUnauthorized, http.StatusUnauthorized},
+			wantCharged: []string{"key:1", "key:1", "ip:203.0.113.7", "ip:203.0.113.7"},
+			wantLookups: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &keyAuth{keys: map[string]int64{"alpha": 1, "beta": 2}}
+			handler, limiter := newRateLimited(auth)
+
+			var status []int
+			for _, key := range tt.keys {
+				status = append(status, get(handler, key).Code)
+			}
+
+			if !reflect.DeepEqual(status, tt.wantStatus) {
+				t.Errorf("statuses = %v, want %v", status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(limiter.charged, tt.wantCharged) {
+				t.Errorf("charged = %v, want %v", limiter.charged, tt.wantCharged)
+			}
+			if auth.lookups != tt.wantLookups {
+				t.Errorf("lookups = %d, want %d", auth.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	handler, _ := newRateLimited(&keyAuth{keys: map[string]int64{"alpha": 1}})
+
+	get(handler, "guess")
+	w := get(handler, "alpha")
+
+	// The key's own quota is reported, not the one of its IP.
+	if got := w.Header().Get("RateLimit-Remaining"); got != "1" {
+		t.Errorf("RateLimit-Remaining = %q, want %q", got, "1")
+	}
+	if got := w.Header().Values("RateLimit-Limit"); len(got) != 1 {
+		t.Errorf("RateLimit-Limit set %d times, want once", len(got))
+	}
+}
@@ -0,0 +1,60 @@
+package httpserver
+
+import (
+	"effectivemobiletesttask/internal/domain/errs"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ParsePathID reads the numeric id from the pNum-th segment of the path.
+func ParsePathID(r *http.Request, pNum int) (int64, error) {
+	return ParsePathInt(r, pNum, "id")
+}
+
+// ParsePathInt reads the integer path parameter name from the pNum-th
+// segment of the path.
+func ParsePathInt(r *http.Request, pNum int, name string) (int64, error) {
+	value, err := strconv.ParseInt(GetPathParameter(r, pNum), 10, 64)
+	if err != nil {
+		return 0, ErrWrongPathParameter.WithFields(errs.Field(name, "must be an integer"))
+	}
+
+	return value, nil
+}
+
+// MaxJSONBody limits the size of JSON request bodies.
+const MaxJSONBody = 1 << 20
+
+// ReadJSON decodes the JSON request body of at most MaxJSONBody bytes into result.
+func ReadJSON(r *http.Request, result any) error {
+	r.Body = http.MaxBytesReader(nil, r.Body, MaxJSONBody)
+
+	if err := jsn.ReadRequestBody(r, result); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return ErrBodyTooLarge.Wrap(err)
+		}
+
+		return ErrInvalidBody.Withf("%s", err)
+	}
+
+	return nil
+}
+
+// ReadBody reads a raw request body of at most limit bytes.
+func ReadBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrBodyTooLarge.Wrap(err)
+		}
+
+		return nil, ErrBadRequest.Wrap(err)
+	}
+
+	return data, nil
+}
@@ -1,21 +1,60 @@
 package httpserver
 
 import (
+	"effectivemobiletesttask/internal/domain/errs"
 	"effectivemobiletesttask/internal/domain/models"
-	"errors"
+	"effectivemobiletesttask/internal/utils/validate"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
+var sortFields = map[string]bool{
+	"id":          true,
+	"name":        true,
+	"releaseDate": true,
+	"group":       true,
+}
+
+var sectionTypes = map[string]bool{
+	models.SectionVerse:  true,
+	models.SectionChorus: true,
+	models.SectionBridge: true,
+	models.SectionIntro:  true,
+	models.SectionOutro:  true,
+}
+
+var searchLanguages = map[string]bool{
+	"russian": true,
+	"english": true,
+}
+
 var (
-	ErrBadRequest         = errors.New("Bad request")
-	ErrInternalServer     = errors.New("Internal server error")
-	ErrWrongPathParameter = errors.New("Wrong path parameter")
-	ErrFieldIsRequired    = errors.New("field is required")
+	ErrBadRequest         = errs.New(errs.KindValidation, "bad_request", "Bad request")
+	ErrInternalServer     = errs.New(errs.KindInternal, "internal", "Internal server error")
+	ErrWrongPathParameter = errs.New(errs.KindValidation, "invalid_path_parameter", "Wrong path parameter")
+	ErrFieldIsRequired    = errs.New(errs.KindValidation, "field_required", "field is required")
+	ErrInvalidParameter   = errs.New(errs.KindValidation, "invalid_parameter", "Invalid query parameter")
+	ErrValidation         = errs.New(errs.KindValidation, "validation_failed", "Validation failed")
+	ErrInvalidBody        = errs.New(errs.KindValidation, "invalid_body", "Request body is not valid JSON")
+	ErrBodyTooLarge       = errs.New(errs.KindTooLarge, "body_too_large", "Request body is too large")
+	ErrUnauthenticated    = errs.New(errs.KindUnauthenticated, "unauthenticated", "Authentication is required")
+	ErrRateLimited        = errs.New(errs.KindRateLimited, "rate_limited", "Too many requests")
 )
 
+func invalidParameter(param string, message string) error {
+	return ErrInvalidParameter.WithFields(errs.Field(param, message))
+}
+
+// RequiredField reports a missing field of the request body.
+func RequiredField(field string) error {
+	return ErrFieldIsRequired.WithFields(errs.Field(field, ErrFieldIsRequired.Message))
+}
+
 type Response struct {
 	Message string `json:"message"`
 	Status  int    `json:"status"`
@@ -30,10 +69,6 @@ func NewResponse(message string, status int, data any) Response {
 	}
 }
 
-func NewErrResponse(message string, status int) Response {
-	return NewResponse(message, status, nil)
-}
-
 func GetPathParameter(r *http.Request, pNum int) string {
 	params := strings.Split(r.URL.Path, "/")
 
@@ -48,16 +83,18 @@ func GetPathParameter(r *http.Request, pNum int) string {
 	}
 }
 
-func ValidateSongRequest(songReq models.SongRequest) (string, error) {
-	if songReq.Name == "" {
-		return "song", ErrFieldIsRequired
+// Validate normalises the request model v points to and checks it against
+// its validate tags. All violations are reported in one error.
+func Validate(v any) error {
+	if violations := validate.Struct(v); len(violations) > 0 {
+		return ErrValidation.WithFields(violations...)
 	}
 
-	if songReq.Group == "" {
-		return "group", ErrFieldIsRequired
-	}
+	return nil
+}
 
-	return "", nil
+func ValidateSongRequest(songReq *models.SongRequest) error {
+	return Validate(songReq)
 }
 
 func ParseReleaseDate(rlsDateStr string) (time.Time, error) {
@@ -68,16 +105,15 @@ func ParseReleaseDate(rlsDateStr string) (time.Time, error) {
 	if err != nil {
 		log.Printf("Invalid releaseDate format: %v", err)
 
-		return time.Time{}, errors.New("invalid releaseDate format. use YYYY-MM-DD")
+		return time.Time{}, ErrValidation.WithFields(errs.Field("releaseDate", "has invalid format, use YYYY-MM-DD"))
 	}
 
 	return releaseDate, nil
 }
 
-func SongToSongResponse(song models.Song, releaseDate time.Time) models.SongResponse {
+func SongUpdateToSongResponse(song models.SongUpdate, releaseDate time.Time) models.SongResponse {
 	var songResp models.SongResponse
 
-	songResp.ID = song.ID
 	songResp.Group = song.Group
 	songResp.Name = song.Name
 	songResp.ReleaseDate = releaseDate
@@ -86,3 +122,278 @@ func SongToSongResponse(song models.Song, releaseDate time.Time) models.SongResp
 
 	return songResp
 }
+
+// ParsePagination reads the cursor, limit and total query parameters. The limit defaults to and is capped by maxLimit.
+func ParsePagination(params url.Values, maxLimit int) (models.Pagination, error) {
+	page := models.Pagination{
+		Cursor: params.Get("cursor"),
+		Limit:  maxLimit,
+	}
+
+	if limitParam := params.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			return models.Pagination{}, invalidParameter("limit", "must be a positive integer")
+		}
+
+		page.Limit = min(limit, maxLimit)
+	}
+
+	withTotal, err := ParseBoolParam(params, "total")
+	if err != nil {
+		return models.Pagination{}, err
+	}
+	page.WithTotal = withTotal
+
+	return page, nil
+}
+
+// ParseSongSort parses a comma separated list of sort fields such as
+// "releaseDate,-name,group". A leading "-" sorts the field in descending
+// order. Only fields from the allow-list are accepted.
+func ParseSongSort(sortParam string) ([]models.SortKey, error) {
+	if sortParam == "" {
+		return nil, nil
+	}
+
+	var keys []models.SortKey
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(sortParam, ",") {
+		part = strings.TrimSpace(part)
+
+		key := models.SortKey{Field: part}
+		if strings.HasPrefix(part, "-") {
+			key = models.SortKey{Field: part[1:], Desc: true}
+		} else if strings.HasPrefix(part, "+") {
+			key = models.SortKey{Field: part[1:]}
+		}
+
+		if !sortFields[key.Field] {
+			return nil, invalidParameter("sort", fmt.Sprintf("contains unknown field %q, allowed: id, name, releaseDate, group", key.Field))
+		}
+
+		if seen[key.Field] {
+			return nil, invalidParameter("sort", fmt.Sprintf("contains field %q more than once", key.Field))
+		}
+		seen[key.Field] = true
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// ParseSongFilter reads song listing filters from the query parameters and
+// validates them. Errors name the offending parameter.
+func ParseSongFilter(params url.Values) (models.SongFilter, error) {
+	var filter models.SongFilter
+	filter.Group = params.Get("group")
+	filter.Name = params.Get("name")
+	filter.Text = params.Get("text")
+	filter.TextContains = params.Get("textContains")
+	filter.Link = params.Get("link")
+
+	dates := []struct {
+		param string
+		dest  *time.Time
+	}{
+		{"releaseDate", &filter.ReleaseDate},
+		{"releaseDateFrom", &filter.ReleaseDateFrom},
+		{"releaseDateTo", &filter.ReleaseDateTo},
+	}
+
+	for _, date := range dates {
+		value := params.Get(date.param)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := ParseReleaseDate(value)
+		if err != nil {
+			return models.SongFilter{}, invalidParameter(date.param, "has invalid format, use YYYY-MM-DD")
+		}
+		*date.dest = parsed
+	}
+
+	if !filter.ReleaseDateFrom.IsZero() && !filter.ReleaseDateTo.IsZero() &&
+		filter.ReleaseDateFrom.After(filter.ReleaseDateTo) {
+		return models.SongFilter{}, invalidParameter("releaseDateFrom", "must not be after 'releaseDateTo'")
+	}
+
+	flags := []struct {
+		param string
+		dest  **bool
+	}{
+		{"hasText", &filter.HasText},
+		{"hasLink", &filter.HasLink},
+	}
+
+	for _, flag := range flags {
+		value := params.Get(flag.param)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return models.SongFilter{}, invalidParameter(flag.param, "must be a boolean")
+		}
+		*flag.dest = &parsed
+	}
+
+	sort, err := ParseSongSort(params.Get("sort"))
+	if err != nil {
+		return models.SongFilter{}, err
+	}
+	filter.Sort = sort
+
+	includeDeleted, err := ParseIncludeDeleted(params)
+	if err != nil {
+		return models.SongFilter{}, err
+	}
+	filter.IncludeDeleted = includeDeleted
+
+	return filter, nil
+}
+
+// ParseIncludeDeleted reads the include_deleted query parameter that makes
+// reads return items from the trash as well.
+func ParseIncludeDeleted(params url.Values) (bool, error) {
+	return ParseBoolParam(params, "include_deleted")
+}
+
+// ParseBoolParam reads the boolean query parameter name, false when it is
+// absent.
+func ParseBoolParam(params url.Values, name string) (bool, error) {
+	value := params.Get(name)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalidParameter(name, "must be a boolean")
+	}
+
+	return parsed, nil
+}
+
+// ParseSongSearch reads the q, lang and page query parameters of a lyrics
+// search. Pages have pageSize hits.
+func ParseSongSearch(params url.Values, pageSize int) (models.SongSearch, error) {
+	search := models.SongSearch{
+		Query:    strings.TrimSpace(params.Get("q")),
+		Language: params.Get("lang"),
+		Limit:    pageSize,
+	}
+
+	if search.Query == "" {
+		return models.SongSearch{}, invalidParameter("q", ErrFieldIsRequired.Message)
+	}
+
+	if search.Language == "" {
+		search.Language = "russian"
+	}
+	if !searchLanguages[search.Language] {
+		return models.SongSearch{}, invalidParameter("lang", "must be russian or english")
+	}
+
+	if pageParam := params.Get("page"); pageParam != "" {
+		page, err := strconv.Atoi(pageParam)
+		if err != nil || page < 0 {
+			return models.SongSearch{}, invalidParameter("page", "must be a non-negative integer")
+		}
+
+		search.Offset = page * pageSize
+	}
+
+	return search, nil
+}
+
+// ParseVerseRange reads the verse_offset and verse_limit query parameters.
+// The limit defaults to and is capped by maxLimit. The legacy verse parameter
+// is treated as verse_offset with a single verse.
+func ParseVerseRange(params url.Values, maxLimit int) (models.VerseRange, error) {
+	verses := models.VerseRange{Limit: maxLimit}
+
+	if verseParam := params.Get("verse"); verseParam != "" {
+		verse, err := strconv.Atoi(verseParam)
+		if err != nil || verse < 0 {
+			return models.VerseRange{}, invalidParameter("verse", "must be a non-negative integer")
+		}
+
+		verses = models.VerseRange{Offset: verse, Limit: 1}
+	}
+
+	if offsetParam := params.Get("verse_offset"); offsetParam != "" {
+		offset, err := strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			return models.VerseRange{}, invalidParameter("verse_offset", "must be a non-negative integer")
+		}
+
+		verses.Offset = offset
+	}
+
+	if limitParam := params.Get("verse_limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			return models.VerseRange{}, invalidParameter("verse_limit", "must be a positive integer")
+		}
+
+		verses.Limit = min(limit, maxLimit)
+	}
+
+	return verses, nil
+}
+
+// ValidateLyrics checks structured lyrics sent by a client. An empty section
+// type defaults to verse.
+func ValidateLyrics(sections []models.LyricsSection) error {
+	for i := range sections {
+		section := &sections[i]
+
+		if section.Type == "" {
+			section.Type = models.SectionVerse
+		}
+
+		if !sectionTypes[section.Type] {
+			return ErrValidation.WithFields(errs.Field(
+				fmt.Sprintf("sections[%d].type", i), "must be one of verse, chorus, bridge, intro, outro",
+			))
+		}
+
+		if len(section.Lines) == 0 {
+			return ErrValidation.WithFields(errs.Field(fmt.Sprintf("sections[%d].lines", i), ErrFieldIsRequired.Message))
+		}
+
+		for j, line := range section.Lines {
+			if line.StartMs != nil && *line.StartMs < 0 {
+				return ErrValidation.WithFields(errs.Field(fmt.Sprintf("sections[%d].lines[%d].startMs", i, j), "must not be negative"))
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseRevisionRange reads the from and to revision numbers of a diff.
+func ParseRevisionRange(params url.Values) (int64, int64, error) {
+	var revisions [2]int64
+
+	for i, param := range []string{"from", "to"} {
+		value := params.Get(param)
+		if value == "" {
+			return 0, 0, invalidParameter(param, ErrFieldIsRequired.Message)
+		}
+
+		revision, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || revision <= 0 {
+			return 0, 0, invalidParameter(param, "must be a positive integer")
+		}
+
+		revisions[i] = revision
+	}
+
+	return revisions[0], revisions[1], nil
+}
@@ -0,0 +1,46 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseBoolParam(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    bool
+		wantErr bool
+	}{
+		{query: "", want: false},
+		{query: "cascade=true", want: true},
+		{query: "cascade=1", want: true},
+		{query: "cascade=false", want: false},
+		{query: "cascade=foo", wantErr: true},
+		{query: "cascade=yes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			params, _ := url.ParseQuery(tt.query)
+
+			got, err := ParseBoolParam(params, "cascade")
+			if tt.wantErr {
+				problem := NewProblem(err, "/group/1")
+				if problem.Status != http.StatusBadRequest {
+					t.Fatalf("ParseBoolParam() error = %v, status %d, want %d", err, problem.Status, http.StatusBadRequest)
+				}
+				if len(problem.Errors) != 1 || problem.Errors[0].Field != "cascade" {
+					t.Errorf("ParseBoolParam() field errors = %+v, want one for cascade", problem.Errors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBoolParam() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBoolParam() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package song
+
+import (
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+)
+
+// GetEnrichment retrieves the enrichment status of a song.
+// @Summary Get song enrichment status
+// @Description Get the enrichment status of a song (pending, enriched, failed) with the number of attempts,
+// @Description the last error and the time of the next retry
+// @Tags enrichment
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} httpserver.Response{data=models.Enrichment}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/enrichment [get]
+func (s *Server) GetEnrichment(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	enrichment, err := s.service.GetEnrichment(r.Context(), id)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched song enrichment", http.StatusOK, enrichment)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// Reenrich queues a song for enrichment again.
+// @Summary Re-run song enrichment
+// @Description Queue the song for enrichment from the song info service again. Only empty details are filled in, details that are already set are kept; clear a field first to refresh it
+// @Tags enrichment
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 202 {object} httpserver.Response{data=models.Enrichment}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 429 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/enrich [post]
+func (s *Server) Reenrich(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	enrichment, err := s.service.Reenrich(r.Context(), id)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Song queued for enrichment", http.StatusAccepted, enrichment)
+
+	jsn.WriteResponseBody(w, resp, http.StatusAccepted)
+}
@@ -0,0 +1,172 @@
+package song
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"effectivemobiletesttask/internal/utils/lyrics"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// GetLyrics retrieves the structured lyrics of a song.
+// @Summary Get song lyrics
+// @Description Get the lyrics of a song as ordered sections (verse, chorus, bridge, intro, outro)
+// @Description with lines and optional line start times in milliseconds
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} httpserver.Response{data=models.Lyrics}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/lyrics [get]
+func (s *Server) GetLyrics(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	songLyrics, err := s.service.GetLyrics(r.Context(), id)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched song lyrics", http.StatusOK, songLyrics)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// UpdateLyrics replaces the structured lyrics of a song.
+// @Summary Update song lyrics
+// @Description Replace all lyrics sections of a song. The plain song text is regenerated from the sections
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param lyrics body models.Lyrics true "Lyrics sections"
+// @Success 200 {object} httpserver.Response{data=models.Lyrics}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/lyrics [put]
+func (s *Server) UpdateLyrics(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	var lyricsReq models.Lyrics
+
+	if err := srv.ReadJSON(r, &lyricsReq); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	if err := srv.ValidateLyrics(lyricsReq.Sections); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	songLyrics, err := s.service.UpdateLyrics(r.Context(), id, lyricsReq.Sections)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully updated song lyrics", http.StatusOK, songLyrics)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// maxLyricsUpload limits the size of uploaded LRC and SRT files.
+const maxLyricsUpload = 1 << 20
+
+var lyricsContentTypes = map[string]string{
+	lyrics.FormatLRC: "text/plain; charset=utf-8",
+	lyrics.FormatSRT: "application/x-subrip; charset=utf-8",
+}
+
+// ExportLyrics returns a handler exporting song lyrics in the given format.
+// @Summary Export synchronized lyrics
+// @Description Export time-stamped song lyrics as LRC or SRT. Every line must have a start time
+// @Tags lyrics
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Success 200 {string} string "LRC or SRT file"
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 409 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/lyrics.lrc [get]
+// @Router /song/{id}/lyrics.srt [get]
+func (s *Server) ExportLyrics(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := srv.ParsePathID(r, 2)
+		if err != nil {
+			srv.WriteError(w, r, err)
+			return
+		}
+
+		data, err := s.service.ExportLyrics(r.Context(), id, format)
+		if err != nil {
+			srv.WriteError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", lyricsContentTypes[format])
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"song-%d.%s\"", id, format))
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, data)
+	}
+}
+
+// ImportLyrics returns a handler replacing song lyrics with an uploaded file
+// in the given format.
+// @Summary Import synchronized lyrics
+// @Description Replace song lyrics with an LRC or SRT upload. Timestamps must not go backwards.
+// @Description The plain song text is regenerated from the upload
+// @Tags lyrics
+// @Accept plain
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param file body string true "LRC or SRT file contents"
+// @Success 200 {object} httpserver.Response{data=models.Lyrics}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/lyrics.lrc [put]
+// @Router /song/{id}/lyrics.srt [put]
+func (s *Server) ImportLyrics(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := srv.ParsePathID(r, 2)
+		if err != nil {
+			srv.WriteError(w, r, err)
+			return
+		}
+
+		data, err := srv.ReadBody(w, r, maxLyricsUpload)
+		if err != nil {
+			srv.WriteError(w, r, err)
+			return
+		}
+
+		songLyrics, err := s.service.ImportLyrics(r.Context(), id, format, string(data))
+		if err != nil {
+			srv.WriteError(w, r, err)
+			return
+		}
+
+		resp := srv.NewResponse("Successfully imported song lyrics", http.StatusOK, songLyrics)
+
+		jsn.WriteResponseBody(w, resp, http.StatusOK)
+	}
+}
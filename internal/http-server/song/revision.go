@@ -0,0 +1,173 @@
+package song
+
+import (
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+	"strconv"
+)
+
+// ListSongRevisions lists the history of a song.
+// @Summary List song revisions
+// @Description Get the revisions of a song, newest first. Every create, update, delete and restore of the song
+// @Description records a revision with the full snapshot, the actor (the authenticated caller) and a timestamp.
+// @Description The history of a deleted song is kept
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param page query int false "Page number"
+// @Success 200 {object} httpserver.Response{data=[]models.SongRevision}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/revisions [get]
+func (s *Server) ListSongRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	page := 0
+	if pageParam := r.URL.Query().Get("page"); pageParam != "" {
+		if parsedPage, err := strconv.Atoi(pageParam); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	revisions, err := s.service.ListSongRevisions(r.Context(), id, s.pageSize*page, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched song revisions", http.StatusOK, revisions)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// GetSongRevision retrieves a single revision of a song.
+// @Summary Get song revision
+// @Description Get a revision of a song with its full snapshot
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} httpserver.Response{data=models.SongRevision}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/revisions/{rev} [get]
+func (s *Server) GetSongRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	revision, err := srv.ParsePathInt(r, 4, "rev")
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	songRevision, err := s.service.GetSongRevision(r.Context(), id, revision)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched song revision", http.StatusOK, songRevision)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// DiffSongRevisions compares two revisions of a song.
+// @Summary Diff song revisions
+// @Description Compare two revisions of a song: changed fields and the lyrics line by line
+// @Description (every line is marked equal, insert or delete)
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param from query int true "Old revision number"
+// @Param to query int true "New revision number"
+// @Success 200 {object} httpserver.Response{data=models.SongRevisionDiff}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/revisions/diff [get]
+func (s *Server) DiffSongRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	from, to, err := srv.ParseRevisionRange(r.URL.Query())
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	diff, err := s.service.DiffSongRevisions(r.Context(), id, from, to)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully compared song revisions", http.StatusOK, diff)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// RestoreSongRevision makes a revision the current state of a song.
+// @Summary Restore song revision
+// @Description Restore the snapshot of a revision as the current song. A song in the trash or already purged is brought back under its id.
+// @Description The restore is recorded as a new revision
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Param If-Match header string false "ETag the song must still have"
+// @Success 200 {object} httpserver.Response{data=models.SongResponse}
+// @Header 200 {string} ETag "New song version"
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 412 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/revisions/{rev}/restore [post]
+func (s *Server) RestoreSongRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	revision, err := srv.ParsePathInt(r, 4, "rev")
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	version, err := s.ifMatch(r, id)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	song, err := s.service.RestoreSongRevision(r.Context(), id, revision, version)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", srv.ETag(song.Version))
+
+	resp := srv.NewResponse("Successfully restored song revision", http.StatusOK, song)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
@@ -1,20 +1,40 @@
 package song
 
 import (
+	"context"
 	"effectivemobiletesttask/internal/domain/models"
+	srv "effectivemobiletesttask/internal/http-server"
+	"effectivemobiletesttask/internal/utils/lyrics"
 	"log/slog"
 	"net/http"
 )
 
 type Service interface {
-	CreateSong(songReq models.SongRequest) (int64, error)
-	GetSongByID(id int64) (models.SongResponse, error)
-	GetSongByName(songName string) (models.SongResponse, error)
-	GetSongTextByID(id int64, verse int) (string, error)
-	GetSongTextByName(songName string, verse int) (string, error)
-	UpdateSong(id int64, song models.SongResponse) (models.SongResponse, error)
-	DeleteSong(id int64) error
-	GetAllSongs(filter models.SongFilter, offset int, limit int) ([]models.SongResponse, error)
+	CreateSong(ctx context.Context, songReq models.SongRequest) (int64, error)
+	GetSongByID(ctx context.Context, id int64, includeDeleted bool) (models.SongResponse, error)
+	GetSongByName(ctx context.Context, songName string) (models.SongResponse, error)
+	GetSongTextByID(ctx context.Context, id int64, verses models.VerseRange) (models.SongText, error)
+	GetSongTextByName(ctx context.Context, songName string, verses models.VerseRange) (models.SongText, error)
+	UpdateSong(ctx context.Context, id int64, song models.SongResponse) (models.SongResponse, error)
+	PatchSong(
+		ctx context.Context,
+		id int64,
+		patch func(current models.SongResponse) (models.SongResponse, error),
+	) (models.SongResponse, error)
+	DeleteSong(ctx context.Context, id int64, version int64) error
+	GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error)
+	SearchSongs(ctx context.Context, search models.SongSearch) ([]models.SongSearchHit, error)
+	GetLyrics(ctx context.Context, id int64) (models.Lyrics, error)
+	UpdateLyrics(ctx context.Context, id int64, sections []models.LyricsSection) (models.Lyrics, error)
+	ExportLyrics(ctx context.Context, id int64, format string) (string, error)
+	ImportLyrics(ctx context.Context, id int64, format string, data string) (models.Lyrics, error)
+	GetEnrichment(ctx context.Context, id int64) (models.Enrichment, error)
+	Reenrich(ctx context.Context, id int64) (models.Enrichment, error)
+	ListSongRevisions(ctx context.Context, id int64, offset int, limit int) ([]models.SongRevision, error)
+	GetSongRevision(ctx context.Context, id int64, revision int64) (models.SongRevision, error)
+	DiffSongRevisions(ctx context.Context, id int64, from int64, to int64) (models.SongRevisionDiff, error)
+	RestoreSongRevision(ctx context.Context, id int64, revision int64, version int64) (models.SongResponse, error)
+	RestoreSong(ctx context.Context, id int64) (models.SongResponse, error)
 }
 
 type Server struct {
@@ -37,8 +57,37 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /song/name", s.GetSongByName)
 	mux.HandleFunc("GET /song/{id}/text", s.GetSongTextByID)
 	mux.HandleFunc("GET /song/name/text", s.GetSongTextByName)
-	mux.HandleFunc("PATCH /song/{id}", s.UpdateSong)
+	mux.HandleFunc("GET /song/{id}/lyrics", s.GetLyrics)
+	mux.HandleFunc("PUT /song/{id}/lyrics", s.UpdateLyrics)
+	mux.HandleFunc("GET /song/{id}/lyrics.lrc", s.ExportLyrics(lyrics.FormatLRC))
+	mux.HandleFunc("GET /song/{id}/lyrics.srt", s.ExportLyrics(lyrics.FormatSRT))
+	mux.HandleFunc("PUT /song/{id}/lyrics.lrc", s.ImportLyrics(lyrics.FormatLRC))
+	mux.HandleFunc("PUT /song/{id}/lyrics.srt", s.ImportLyrics(lyrics.FormatSRT))
+	mux.HandleFunc("GET /song/{id}/enrichment", s.GetEnrichment)
+	mux.HandleFunc("POST /song/{id}/enrich", s.Reenrich)
+	mux.HandleFunc("GET /song/{id}/revisions", s.ListSongRevisions)
+	mux.HandleFunc("GET /song/{id}/revisions/diff", s.DiffSongRevisions)
+	mux.HandleFunc("GET /song/{id}/revisions/{rev}", s.GetSongRevision)
+	mux.HandleFunc("POST /song/{id}/revisions/{rev}/restore", s.RestoreSongRevision)
+	mux.HandleFunc("POST /song/{id}/restore", s.RestoreSong)
+	mux.HandleFunc("PATCH /song/{id}", s.PatchSong)
 	mux.HandleFunc("PUT /song/{id}", s.UpdateSong)
 	mux.HandleFunc("DELETE /song/{id}", s.DeleteSong)
 	mux.HandleFunc("GET /song/all", s.GetAllSongs)
+	mux.HandleFunc("GET /song/search", s.SearchSongs)
+}
+
+// ifMatch returns the version a write to the song has to be conditional on
+// according to the If-Match header, 0 when the request is unconditional.
+func (s *Server) ifMatch(r *http.Request, id int64) (int64, error) {
+	if r.Header.Get("If-Match") == "" {
+		return 0, nil
+	}
+
+	current, err := s.service.GetSongByID(r.Context(), id, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return srv.IfMatch(r, current.Version)
 }
@@ -3,100 +3,96 @@ package song
 import (
 	"effectivemobiletesttask/internal/domain/models"
 	srv "effectivemobiletesttask/internal/http-server"
-	"effectivemobiletesttask/internal/storage"
 	jsn "effectivemobiletesttask/internal/utils/json"
 	"errors"
-	"fmt"
 	"net/http"
-	"strconv"
-	"time"
 )
 
 // CreateSong adds a new song to the library.
 // @Summary Add a new song
-// @Description Add a new song to the music library
+// @Description Add a new song to the music library. The song is created with the "pending" enrichment status,
+// @Description release date, text and link are filled in later from the song info service.
+// @Description Group and song are required and limited to 255 characters
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param song body models.SongRequest true "Song Request"
 // @Success 201 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 429 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/create [post]
 func (s *Server) CreateSong(w http.ResponseWriter, r *http.Request) {
 	var songReq models.SongRequest
-	var resp srv.Response
 
-	if err := jsn.ReadRequestBody(r, &songReq); err != nil {
-		resp = srv.NewErrResponse(srv.ErrBadRequest.Error(), http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+	if err := srv.ReadJSON(r, &songReq); err != nil {
+		srv.WriteError(w, r, err)
 		return
 	}
 
 	defer r.Body.Close()
 
-	field, err := srv.ValidateSongRequest(songReq)
-	if err != nil {
-		resp = srv.NewErrResponse(fmt.Sprintf("'%s' %s", field, err.Error()), http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+	if err := srv.ValidateSongRequest(&songReq); err != nil {
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	id, err := s.service.CreateSong(songReq)
+	id, err := s.service.CreateSong(r.Context(), songReq)
 	if err != nil {
-		resp = srv.NewErrResponse(srv.ErrInternalServer.Error(), http.StatusInternalServerError)
-
-		jsn.WriteResponseBody(w, resp, http.StatusInternalServerError)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	resp = srv.NewResponse("Added new song", http.StatusCreated, id)
+	resp := srv.NewResponse("Added new song", http.StatusCreated, id)
 
 	jsn.WriteResponseBody(w, resp, http.StatusCreated)
 }
 
 // GetSongByID retrieves a song by its ID.
 // @Summary Get song by ID
-// @Description Get a specific song by its unique ID
+// @Description Get a specific song by its unique ID. The ETag header carries the song version,
+// @Description send it back in If-None-Match to get 304 while the song is unchanged
 // @Tags songs
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param include_deleted query bool false "Return the song even if it is in the trash"
+// @Param If-None-Match header string false "ETag of the cached copy"
 // @Success 200 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 404 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Header 200 {string} ETag "Song version"
+// @Success 304 "Song was not modified"
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/{id} [get]
 func (s *Server) GetSongByID(w http.ResponseWriter, r *http.Request) {
-	idStr := srv.GetPathParameter(r, 2)
-	id, err := strconv.ParseInt(idStr, 10, 64)
-
-	var resp srv.Response
-
+	id, err := srv.ParsePathID(r, 2)
 	if err != nil {
-		resp = srv.NewErrResponse(srv.ErrWrongPathParameter.Error(), http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	song, err := s.service.GetSongByID(id)
+	includeDeleted, err := srv.ParseIncludeDeleted(r.URL.Query())
 	if err != nil {
-		if errors.Is(err, storage.ErrSongNotFound) {
-			resp = srv.NewErrResponse("Song was not found", http.StatusNotFound)
+		srv.WriteError(w, r, err)
+		return
+	}
 
-			jsn.WriteResponseBody(w, resp, http.StatusNotFound)
-			return
-		}
+	song, err := s.service.GetSongByID(r.Context(), id, includeDeleted)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
 
-		resp = srv.NewErrResponse(srv.ErrInternalServer.Error(), http.StatusInternalServerError)
+	w.Header().Set("ETag", srv.ETag(song.Version))
 
-		jsn.WriteResponseBody(w, resp, http.StatusInternalServerError)
+	if srv.NotModified(r, song.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	resp = srv.NewResponse("Successfully fetched song", http.StatusOK, song)
+	resp := srv.NewResponse("Successfully fetched song", http.StatusOK, song)
 
 	jsn.WriteResponseBody(w, resp, http.StatusOK)
 }
@@ -109,326 +105,357 @@ func (s *Server) GetSongByID(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param song body models.SongName true "Song Name"
 // @Success 200 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 404 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/name [get]
 func (s *Server) GetSongByName(w http.ResponseWriter, r *http.Request) {
-	var songReq models.SongRequest
-	var resp srv.Response
-
-	if err := jsn.ReadRequestBody(r, &songReq); err != nil {
-		resp = srv.NewErrResponse(srv.ErrBadRequest.Error(), http.StatusBadRequest)
+	var songName models.SongName
 
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+	if err := srv.ReadJSON(r, &songName); err != nil {
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	if songReq.Name == "" {
-		resp = srv.NewErrResponse(fmt.Sprintf("'song' %s", srv.ErrFieldIsRequired.Error()), http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+	if err := srv.Validate(&songName); err != nil {
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	song, err := s.service.GetSongByName(songReq.Name)
+	song, err := s.service.GetSongByName(r.Context(), songName.Name)
 	if err != nil {
-		if errors.Is(err, storage.ErrSongNotFound) {
-			resp = srv.NewErrResponse("Song was not found", http.StatusNotFound)
-
-			jsn.WriteResponseBody(w, resp, http.StatusNotFound)
-			return
-		}
-
-		resp = srv.NewErrResponse(srv.ErrInternalServer.Error(), http.StatusInternalServerError)
-
-		jsn.WriteResponseBody(w, resp, http.StatusInternalServerError)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	resp = srv.NewResponse("Successfully fetched song", http.StatusOK, song)
+	resp := srv.NewResponse("Successfully fetched song", http.StatusOK, song)
 
 	jsn.WriteResponseBody(w, resp, http.StatusOK)
 }
 
 // GetSongTextByName retrieves the text of a song by its name.
 // @Summary Get song text by name
-// @Description Fetch the text of a specific song using its name
+// @Description Fetch the verses of a specific song using its name, paginated by verse
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param name query string false "Name"
-// @Param verse query int false "Verse"
-// @Success 200 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 404 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Param verse_offset query int false "Index of the first verse to return"
+// @Param verse_limit query int false "Number of verses to return, capped by the configured page size"
+// @Param verse query int false "Deprecated: single verse index, same as verse_offset with verse_limit=1"
+// @Success 200 {object} httpserver.Response{data=models.SongText}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 416 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/name/text [get]
 func (s *Server) GetSongTextByName(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
 	name := params.Get("name")
-	verseParam := params.Get("verse")
-
-	verse := 0
 
-	if verseParam != "" {
-		if parsedVerse, err := strconv.Atoi(verseParam); err == nil {
-			verse = parsedVerse
-		}
+	verses, err := srv.ParseVerseRange(params, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
 	}
 
-	var resp srv.Response
-
-	text, err := s.service.GetSongTextByName(name, verse)
+	text, err := s.service.GetSongTextByName(r.Context(), name, verses)
 	if err != nil {
-		if errors.Is(err, storage.ErrSongNotFound) {
-			resp = srv.NewErrResponse("Song was not found", http.StatusNotFound)
-
-			jsn.WriteResponseBody(w, resp, http.StatusNotFound)
-			return
-		}
-
-		resp = srv.NewErrResponse(srv.ErrInternalServer.Error(), http.StatusInternalServerError)
-
-		jsn.WriteResponseBody(w, resp, http.StatusInternalServerError)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	resp = srv.NewResponse("Successfully fetched song text", http.StatusOK, text)
+	resp := srv.NewResponse("Successfully fetched song text", http.StatusOK, text)
 
-	jsn.WriteResponseBody(w, resp, http.StatusInternalServerError)
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
 }
 
 // GetSongTextByID retrieves the text of a song by its ID.
 // @Summary Get song text by ID
-// @Description Fetch the text of a specific song using its ID
+// @Description Fetch the verses of a specific song using its ID, paginated by verse
 // @Tags songs
 // @Produce json
 // @Param id path int true "Song ID"
-// @Param verse query int false "Verse"
-// @Success 200 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 404 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Param verse_offset query int false "Index of the first verse to return"
+// @Param verse_limit query int false "Number of verses to return, capped by the configured page size"
+// @Param verse query int false "Deprecated: single verse index, same as verse_offset with verse_limit=1"
+// @Success 200 {object} httpserver.Response{data=models.SongText}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 416 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/{id}/text [get]
 func (s *Server) GetSongTextByID(w http.ResponseWriter, r *http.Request) {
-	verseParam := r.URL.Query().Get("verse")
-	verse := 0
-
-	if verseParam != "" {
-		if parsedVerse, err := strconv.Atoi(verseParam); err == nil {
-			verse = parsedVerse
-		}
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
 	}
 
-	idStr := srv.GetPathParameter(r, 2)
-	id, err := strconv.ParseInt(idStr, 10, 64)
-
-	var resp srv.Response
-
+	verses, err := srv.ParseVerseRange(r.URL.Query(), s.pageSize)
 	if err != nil {
-		resp = srv.NewErrResponse(srv.ErrWrongPathParameter.Error(), http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	text, err := s.service.GetSongTextByID(id, verse)
+	text, err := s.service.GetSongTextByID(r.Context(), id, verses)
 	if err != nil {
-		if errors.Is(err, storage.ErrSongNotFound) {
-			resp = srv.NewErrResponse("Song was not found", http.StatusNotFound)
-
-			jsn.WriteResponseBody(w, resp, http.StatusNotFound)
-			return
-		}
-
-		resp = srv.NewErrResponse(srv.ErrInternalServer.Error(), http.StatusInternalServerError)
-
-		jsn.WriteResponseBody(w, resp, http.StatusInternalServerError)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	resp = srv.NewResponse("Successfully fetched song", http.StatusOK, text)
+	resp := srv.NewResponse("Successfully fetched song text", http.StatusOK, text)
 
 	jsn.WriteResponseBody(w, resp, http.StatusOK)
 }
 
 // UpdateSong updates details of an existing song.
 // @Summary Update song
-// @Description Update the details of a song by its ID
+// @Description Update the details of a song by its ID. All violated rules are reported at once:
+// @Description names up to 255 characters, releaseDate not in the future, link an http(s) URL
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
-// @Param song body models.SongResponse true "Updated Song Data"
+// @Param If-Match header string false "ETag the song must still have"
+// @Param song body models.SongUpdate true "Updated Song Data"
 // @Success 200 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 404 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Header 200 {string} ETag "New song version"
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 412 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/{id} [put]
-// @Router /song/{id} [patch]
 func (s *Server) UpdateSong(w http.ResponseWriter, r *http.Request) {
-	idStr := srv.GetPathParameter(r, 2)
-	id, err := strconv.ParseInt(idStr, 10, 64)
-
-	var resp srv.Response
-
+	id, err := srv.ParsePathID(r, 2)
 	if err != nil {
-		resp = srv.NewErrResponse(srv.ErrWrongPathParameter.Error(), http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	var newSong models.Song
+	var newSong models.SongUpdate
 
-	if err := jsn.ReadRequestBody(r, &newSong); err != nil {
-		resp = srv.NewErrResponse("Error during decoding JSON", http.StatusBadRequest)
+	if err := srv.ReadJSON(r, &newSong); err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
 
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+	if err := srv.Validate(&newSong); err != nil {
+		srv.WriteError(w, r, err)
 		return
 	}
 
 	releaseDate, err := srv.ParseReleaseDate(newSong.ReleaseDate)
 	if err != nil {
-		resp = srv.NewErrResponse("Error during parsing release date", http.StatusBadRequest)
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	songResp := srv.SongUpdateToSongResponse(newSong, releaseDate)
+
+	songResp.Version, err = s.ifMatch(r, id)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
 
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+	song, err := s.service.UpdateSong(r.Context(), id, songResp)
+	if err != nil {
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	songResp := srv.SongToSongResponse(newSong, releaseDate)
+	w.Header().Set("ETag", srv.ETag(song.Version))
+
+	resp := srv.NewResponse("Successfully updated song", http.StatusOK, song)
 
-	song, err := s.service.UpdateSong(id, songResp)
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
+
+// PatchSong changes only the supplied fields of a song.
+// @Summary Patch song
+// @Description Partially update a song with a JSON Merge Patch (application/merge-patch+json, also used for
+// @Description application/json) or a JSON Patch (application/json-patch+json) applied to
+// @Description {"song", "group", "releaseDate", "text", "link"}. Returns the song as stored
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag the song must still have"
+// @Param patch body models.SongDocument true "Merge patch or JSON Patch operations"
+// @Success 200 {object} httpserver.Response{data=models.SongResponse}
+// @Header 200 {string} ETag "New song version"
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 409 {object} httpserver.Problem
+// @Failure 412 {object} httpserver.Problem
+// @Failure 415 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id} [patch]
+func (s *Server) PatchSong(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
 	if err != nil {
-		if errors.Is(err, storage.ErrSongNotFound) {
-			resp = srv.NewErrResponse("Song was not found", http.StatusNotFound)
+		srv.WriteError(w, r, err)
+		return
+	}
 
-			jsn.WriteResponseBody(w, resp, http.StatusNotFound)
-			return
+	patch, err := srv.ReadBody(w, r, srv.MaxJSONBody)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	song, err := s.service.PatchSong(r.Context(), id, func(current models.SongResponse) (models.SongResponse, error) {
+		if _, err := srv.IfMatch(r, current.Version); err != nil {
+			return models.SongResponse{}, err
 		}
 
-		resp = srv.NewErrResponse("Error during fetching song", http.StatusBadRequest)
+		newSong, err := srv.PatchSong(current, r.Header.Get("Content-Type"), patch)
+		if err != nil {
+			return models.SongResponse{}, err
+		}
 
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+		return srv.SongDocumentToSongResponse(id, newSong), nil
+	})
+	if err != nil {
+		if errors.Is(err, srv.ErrUnsupportedPatch) {
+			w.Header().Set("Accept-Patch", srv.AcceptPatch)
+		}
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	resp = srv.NewResponse("Successfully updated song", http.StatusOK, song)
+	w.Header().Set("ETag", srv.ETag(song.Version))
+
+	resp := srv.NewResponse("Successfully patched song", http.StatusOK, song)
 
 	jsn.WriteResponseBody(w, resp, http.StatusOK)
 }
 
-// DeleteSong removes a song from the library.
+// DeleteSong moves a song to the trash.
 // @Summary Delete song
-// @Description Delete a specific song by its ID
+// @Description Move a specific song to the trash. It can be restored until the trash is purged
 // @Tags songs
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag the song must still have"
 // @Success 204 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 404 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 412 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/{id} [delete]
 func (s *Server) DeleteSong(w http.ResponseWriter, r *http.Request) {
-	idStr := srv.GetPathParameter(r, 2)
-	id, err := strconv.ParseInt(idStr, 10, 64)
-
-	var resp srv.Response
-
+	id, err := srv.ParsePathID(r, 2)
 	if err != nil {
-		resp = srv.NewErrResponse(srv.ErrWrongPathParameter.Error(), http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	err = s.service.DeleteSong(id)
+	version, err := s.ifMatch(r, id)
 	if err != nil {
-		if errors.Is(err, storage.ErrSongNotFound) {
-			resp = srv.NewErrResponse("Song was not found", http.StatusNotFound)
-
-			jsn.WriteResponseBody(w, resp, http.StatusNotFound)
-			return
-		}
-
-		resp = srv.NewErrResponse("Error during fetching song", http.StatusBadRequest)
+		srv.WriteError(w, r, err)
+		return
+	}
 
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+	err = s.service.DeleteSong(r.Context(), id, version)
+	if err != nil {
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	resp = srv.NewResponse("Successfully deleted song", http.StatusNoContent, nil)
+	resp := srv.NewResponse("Successfully deleted song", http.StatusNoContent, nil)
 
 	jsn.WriteResponseBody(w, resp, http.StatusNoContent)
 }
 
 // GetAllSongs retrieves songs matching specific filters.
 // @Summary Get all songs
-// @Description Fetch songs that match the provided filters
+// @Description Fetch songs that match the provided filters. Results are paginated with an opaque cursor:
+// @Description pass next_cursor of the previous page as cursor to get the next one
 // @Tags songs
 // @Produce json
-// @Param group query string false "Song group"
-// @Param name query string false "Song name"
-// @Param text query string false "Song text"
+// @Param group query string false "Song group (case-insensitive substring)"
+// @Param name query string false "Song name (case-insensitive substring)"
+// @Param text query string false "Song text (exact match)"
+// @Param textContains query string false "Substring of the song text (case-insensitive)"
 // @Param link query string false "External link"
+// @Param hasText query bool false "Only songs with (true) or without (false) text"
+// @Param hasLink query bool false "Only songs with (true) or without (false) link"
 // @Param releaseDate query string false "Release date (YYYY-MM-DD)"
-// @Param page query int false "Page number"
-// @Success 200 {object} httpserver.Response
-// @Failure 400 {object} httpserver.Response
-// @Failure 500 {object} httpserver.Response
+// @Param releaseDateFrom query string false "Released on or after (YYYY-MM-DD)"
+// @Param releaseDateTo query string false "Released on or before (YYYY-MM-DD)"
+// @Param sort query string false "Comma separated sort fields (id, name, releaseDate, group), prefix with - for descending, e.g. releaseDate,-name"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param limit query int false "Page size, capped by the configured page size"
+// @Param total query bool false "Include total count of matching songs"
+// @Param include_deleted query bool false "Include songs in the trash"
+// @Success 200 {object} httpserver.Response{data=models.SongPage}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
 // @Router /song/all [get]
 func (s *Server) GetAllSongs(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
 
-	var resp srv.Response
-
-	var filter models.SongFilter
-	filter.Group = params.Get("group")
-	filter.Name = params.Get("name")
-	filter.Text = params.Get("text")
-	filter.Link = params.Get("link")
-	filter.ReleaseDate = time.Time{}
-
-	rlsDateParam := params.Get("releaseDate")
+	filter, err := srv.ParseSongFilter(params)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
 
-	if rlsDateParam != "" {
-		rlsDate, err := srv.ParseReleaseDate(rlsDateParam)
+	page, err := srv.ParsePagination(params, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			resp = srv.NewErrResponse(err.Error(), http.StatusBadRequest)
+	songs, err := s.service.GetAllSongs(r.Context(), filter, page)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
 
-			jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
-			return
-		}
+	resp := srv.NewResponse("Successfully fetched songs", http.StatusOK, songs)
 
-		filter.ReleaseDate = rlsDate
-	}
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
 
-	pageParam := params.Get("page")
-	page := 0
+// SearchSongs performs a full-text search over song lyrics.
+// @Summary Search songs by lyrics
+// @Description Full-text search over song lyrics ranked by relevance. Every hit contains a highlighted
+// @Description snippet of the best matching verse
+// @Tags songs
+// @Produce json
+// @Param q query string true "Search query (websearch syntax: quoted phrases, OR, -exclusion)"
+// @Param lang query string false "Text search language: russian (default) or english"
+// @Param page query int false "Page number"
+// @Success 200 {object} httpserver.Response{data=[]models.SongSearchHit}
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/search [get]
+func (s *Server) SearchSongs(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
 
-	if pageParam != "" {
-		if parsedPage, err := strconv.Atoi(pageParam); err == nil {
-			page = parsedPage
-		}
+	search, err := srv.ParseSongSearch(params, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
 	}
 
-	songs, err := s.service.GetAllSongs(filter, s.pageSize*page, s.pageSize)
+	hits, err := s.service.SearchSongs(r.Context(), search)
 	if err != nil {
-		if errors.Is(err, storage.ErrGroupNotFound) {
-			resp = srv.NewErrResponse("Group was not found", http.StatusBadRequest)
-
-			jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
-			return
-		}
-
-		resp = srv.NewErrResponse("Error during fetching songs", http.StatusBadRequest)
-
-		jsn.WriteResponseBody(w, resp, http.StatusBadRequest)
+		srv.WriteError(w, r, err)
 		return
 	}
 
-	resp = srv.NewResponse("Successfully fetched songs", http.StatusOK, songs)
+	resp := srv.NewResponse("Successfully searched songs", http.StatusOK, hits)
 
 	jsn.WriteResponseBody(w, resp, http.StatusOK)
 }
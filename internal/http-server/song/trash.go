@@ -0,0 +1,42 @@
+package song
+
+import (
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+)
+
+// RestoreSong takes a song out of the trash.
+// @Summary Restore song from trash
+// @Description Restore a deleted song. Its group is restored with it when it is in the trash too.
+// @Description The restore is recorded as a new revision
+// @Tags trash
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} httpserver.Response{data=models.SongResponse}
+// @Header 200 {string} ETag "New song version"
+// @Failure 400 {object} httpserver.Problem
+// @Failure 403 {object} httpserver.Problem
+// @Failure 404 {object} httpserver.Problem
+// @Failure 409 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /song/{id}/restore [post]
+func (s *Server) RestoreSong(w http.ResponseWriter, r *http.Request) {
+	id, err := srv.ParsePathID(r, 2)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	song, err := s.service.RestoreSong(r.Context(), id)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", srv.ETag(song.Version))
+
+	resp := srv.NewResponse("Successfully restored song", http.StatusOK, song)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
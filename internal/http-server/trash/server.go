@@ -0,0 +1,30 @@
+package trash
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"log/slog"
+	"net/http"
+)
+
+type Service interface {
+	ListTrash(ctx context.Context, offset int, limit int) ([]models.TrashItem, error)
+}
+
+type Server struct {
+	log      *slog.Logger
+	pageSize int
+	service  Service
+}
+
+func New(log *slog.Logger, pageSize int, service Service) *Server {
+	return &Server{
+		log:      log,
+		pageSize: pageSize,
+		service:  service,
+	}
+}
+
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /trash", s.ListTrash)
+}
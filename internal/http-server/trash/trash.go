@@ -0,0 +1,38 @@
+package trash
+
+import (
+	srv "effectivemobiletesttask/internal/http-server"
+	jsn "effectivemobiletesttask/internal/utils/json"
+	"net/http"
+	"strconv"
+)
+
+// ListTrash lists deleted songs and groups.
+// @Summary List trash
+// @Description Get deleted songs and groups, most recently deleted first. purgeAt is the time the purge job
+// @Description removes an item for good; until then it can be restored
+// @Tags trash
+// @Produce json
+// @Param page query int false "Page number"
+// @Success 200 {object} httpserver.Response{data=[]models.TrashItem}
+// @Failure 403 {object} httpserver.Problem
+// @Failure 500 {object} httpserver.Problem
+// @Router /trash [get]
+func (s *Server) ListTrash(w http.ResponseWriter, r *http.Request) {
+	page := 0
+	if pageParam := r.URL.Query().Get("page"); pageParam != "" {
+		if parsedPage, err := strconv.Atoi(pageParam); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	items, err := s.service.ListTrash(r.Context(), s.pageSize*page, s.pageSize)
+	if err != nil {
+		srv.WriteError(w, r, err)
+		return
+	}
+
+	resp := srv.NewResponse("Successfully fetched trash", http.StatusOK, items)
+
+	jsn.WriteResponseBody(w, resp, http.StatusOK)
+}
@@ -4,41 +4,140 @@ import (
 	"effectivemobiletesttask/internal/config"
 	"errors"
 	"fmt"
-	"log"
 	"log/slog"
+	"os"
+	"sync"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-func RunMigrations(cfgDB *config.DBStorage, cfgMigr *config.Migrations) {
+var (
+	ErrDirty    = errors.New("last migration failed, database is dirty")
+	ErrOutdated = errors.New("database is not at the latest migration")
+)
+
+func newMigrate(cfgDB *config.DBStorage, cfgMigr *config.Migrations) (*migrate.Migrate, error) {
 	if cfgMigr.Path == "" {
-		log.Fatal("migrations-path is required")
+		return nil, errors.New("migrations-path is required")
 	}
 
 	DBUrl := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s&x-migrations-table=%s",
 		cfgDB.User, cfgDB.Pass, cfgDB.Host, cfgDB.Port, cfgDB.DBName, cfgDB.SSLMode, cfgMigr.Table)
 
-	m, err := migrate.New(
+	return migrate.New(
 		"file://"+cfgMigr.Path,
 		DBUrl,
 	)
+}
 
+func RunMigrations(log *slog.Logger, cfgDB *config.DBStorage, cfgMigr *config.Migrations) error {
+	const op = "migrator.RunMigrations"
+
+	m, err := newMigrate(cfgDB, cfgMigr)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer m.Close()
 
 	if err := m.Up(); err != nil {
 		if errors.Is(err, migrate.ErrNoChange) {
-			slog.Debug("no migrations to apply")
-			fmt.Println("no migrations to apply")
+			log.Debug("no migrations to apply")
+
+			return nil
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("migrations applied")
+
+	return nil
+}
 
-			return
+// LatestVersion returns the version of the newest migration in path.
+func LatestVersion(path string) (uint, error) {
+	const op = "migrator.LatestVersion"
+
+	src, err := source.Open("file://" + path)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
 		}
+		version = next
+	}
+}
+
+// Checker reports whether the database is at the newest migration. It keeps
+// a connection of its own open until it is closed.
+type Checker struct {
+	mu     sync.Mutex
+	m      *migrate.Migrate
+	latest uint
+}
+
+func NewChecker(cfgDB *config.DBStorage, cfgMigr *config.Migrations) (*Checker, error) {
+	const op = "migrator.NewChecker"
+
+	latest, err := LatestVersion(cfgMigr.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := newMigrate(cfgDB, cfgMigr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Checker{m: m, latest: latest}, nil
+}
+
+// Check returns ErrDirty or ErrOutdated when the database is not at the
+// newest migration.
+func (c *Checker) Check() error {
+	const op = "migrator.Check"
+
+	c.mu.Lock()
+	version, dirty, err := c.m.Version()
+	c.mu.Unlock()
+
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if dirty {
+		return fmt.Errorf("%s: %w: version %d", op, ErrDirty, version)
+	}
+
+	if version != c.latest {
+		return fmt.Errorf("%s: %w: version %d, latest %d", op, ErrOutdated, version, c.latest)
+	}
+
+	return nil
+}
+
+func (c *Checker) Close() error {
+	const op = "migrator.Close"
 
-		log.Fatal(err)
+	if srcErr, dbErr := c.m.Close(); srcErr != nil || dbErr != nil {
+		return fmt.Errorf("%s: %w", op, errors.Join(srcErr, dbErr))
 	}
 
-	log.Println("migrations applied")
+	return nil
 }
@@ -0,0 +1,221 @@
+// Package auth authenticates callers by API key or bearer JWT and manages
+// the stored API keys.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/domain/actor"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/domain/principal"
+	"effectivemobiletesttask/internal/services"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/jwt"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+const (
+	// KeyPrefix starts every API key so that leaked keys are easy to spot.
+	KeyPrefix = "sl_"
+	// BootstrapSubject is the principal of the bootstrap key.
+	BootstrapSubject = "bootstrap"
+
+	keyBytes     = 32
+	prefixLength = len(KeyPrefix) + 8
+)
+
+type KeyStore interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey, hash string, roles []string) (models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context, offset int, limit int) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	GetSubjectRoles(ctx context.Context, subject string) ([]string, error)
+}
+
+type Service struct {
+	log           *slog.Logger
+	store         KeyStore
+	verifier      *jwt.Verifier
+	bootstrapHash string
+}
+
+// New builds the service from the auth config. Bearer tokens are rejected
+// when neither a JWT secret nor a JWKS file is configured.
+func New(log *slog.Logger, store KeyStore, cfg config.Auth) (*Service, error) {
+	const op = "services.auth.New"
+
+	s := &Service{
+		log:   log,
+		store: store,
+	}
+
+	if cfg.BootstrapKey != "" {
+		s.bootstrapHash = hashKey(cfg.BootstrapKey)
+	}
+
+	opts := jwt.Options{
+		Secret:   []byte(cfg.JWT.Secret),
+		Issuer:   cfg.JWT.Issuer,
+		Audience: cfg.JWT.Audience,
+		Leeway:   cfg.JWT.Leeway,
+	}
+
+	if cfg.JWT.JWKSFile != "" {
+		keys, err := jwt.LoadJWKS(cfg.JWT.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		opts.Keys = keys
+	}
+
+	if len(opts.Secret) > 0 || len(opts.Keys) > 0 {
+		s.verifier = jwt.NewVerifier(opts)
+	}
+
+	return s, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// withRoles adds the roles granted to p, to its key ID for API keys or to
+// its subject for tokens, to the given ones.
+func (s *Service) withRoles(ctx context.Context, p principal.Principal, roles ...string) (principal.Principal, error) {
+	granted, err := s.store.GetSubjectRoles(ctx, p.GrantSubject())
+	if err != nil {
+		return principal.Principal{}, err
+	}
+
+	p.Roles = append([]string{}, roles...)
+	for _, role := range granted {
+		if !p.HasRole(role) {
+			p.Roles = append(p.Roles, role)
+		}
+	}
+
+	return p, nil
+}
+
+// AuthenticateAPIKey resolves an API key to its principal. The bootstrap key
+// is always an admin.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (principal.Principal, error) {
+	const op = "services.auth.AuthenticateAPIKey"
+
+	hash := hashKey(key)
+
+	if s.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(s.bootstrapHash)) == 1 {
+		return principal.Principal{
+			Subject: BootstrapSubject,
+			Method:  principal.MethodAPIKey,
+			Roles:   []string{principal.RoleAdmin},
+		}, nil
+	}
+
+	stored, err := s.store.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return principal.Principal{}, services.ErrInvalidAPIKey
+		}
+
+		s.log.ErrorContext(ctx, "error looking up API key", lg.Err(err))
+		return principal.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p, err := s.withRoles(ctx, principal.Principal{Subject: stored.Name, Method: principal.MethodAPIKey, KeyID: stored.ID})
+	if err != nil {
+		s.log.ErrorContext(ctx, "error looking up roles", lg.Err(err))
+		return principal.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// AuthenticateToken resolves a bearer JWT to its principal, the sub claim.
+// The roles claim adds to the roles granted to the subject.
+func (s *Service) AuthenticateToken(ctx context.Context, token string) (principal.Principal, error) {
+	const op = "services.auth.AuthenticateToken"
+
+	if s.verifier == nil {
+		return principal.Principal{}, services.ErrInvalidToken.Withf("bearer tokens are not accepted")
+	}
+
+	claims, err := s.verifier.Verify(token)
+	if err != nil {
+		s.log.DebugContext(ctx, "rejected bearer token", lg.Err(err))
+		return principal.Principal{}, services.ErrInvalidToken.Withf("%s", err)
+	}
+
+	p, err := s.withRoles(ctx, principal.Principal{Subject: claims.Subject, Method: principal.MethodJWT}, claims.Roles...)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error looking up roles", lg.Err(err))
+		return principal.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// CreateAPIKey generates a new key and grants roles to it. The returned
+// secret is not stored and cannot be shown again.
+func (s *Service) CreateAPIKey(ctx context.Context, name string, roles []string) (models.CreatedAPIKey, error) {
+	const op = "services.auth.CreateAPIKey"
+
+	secret := make([]byte, keyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return models.CreatedAPIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	key := KeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	stored, err := s.store.CreateAPIKey(ctx, models.APIKey{
+		Name:      name,
+		Prefix:    key[:prefixLength],
+		CreatedBy: actor.From(ctx),
+	}, hashKey(key), roles)
+	if err != nil {
+		if errors.Is(err, storage.ErrRoleNotFound) {
+			return models.CreatedAPIKey{}, err
+		}
+
+		s.log.ErrorContext(ctx, "error creating API key", lg.Err(err))
+		return models.CreatedAPIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.InfoContext(ctx, "created API key",
+		slog.Int64("keyID", stored.ID),
+		slog.String("name", name),
+		slog.Any("roles", roles),
+	)
+	return models.CreatedAPIKey{APIKey: stored, Key: key}, nil
+}
+
+func (s *Service) ListAPIKeys(ctx context.Context, offset int, limit int) ([]models.APIKey, error) {
+	const op = "services.auth.ListAPIKeys"
+
+	keys, err := s.store.ListAPIKeys(ctx, offset, limit)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error listing API keys", lg.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) error {
+	const op = "services.auth.RevokeAPIKey"
+
+	if err := s.store.RevokeAPIKey(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.InfoContext(ctx, "revoked API key", slog.Int64("keyID", id))
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package health checks the dependencies the service needs to serve requests.
+package health
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	fn       Check
+}
+
+type Service struct {
+	log     *slog.Logger
+	timeout time.Duration
+	checks  []check
+}
+
+// New returns a service that gives every check timeout to complete.
+func New(log *slog.Logger, timeout time.Duration) *Service {
+	return &Service{
+		log:     log,
+		timeout: timeout,
+	}
+}
+
+// Add registers a check. The service is not ready while a critical check
+// fails; a failing non-critical check only degrades it.
+func (s *Service) Add(name string, critical bool, fn Check) {
+	s.checks = append(s.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Live reports that the process is up. It checks no dependency.
+func (s *Service) Live() models.Health {
+	return models.Health{Status: models.HealthUp}
+}
+
+// Ready runs all checks concurrently and sums them up.
+func (s *Service) Ready(ctx context.Context) models.Health {
+	results := make([]models.CheckResult, len(s.checks))
+
+	var wg sync.WaitGroup
+	for i, c := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	health := models.Health{Status: models.HealthUp, Checks: make(map[string]models.CheckResult, len(s.checks))}
+
+	for i, c := range s.checks {
+		res := results[i]
+		health.Checks[c.name] = res
+
+		if res.Status == models.HealthUp {
+			continue
+		}
+
+		if c.critical {
+			health.Status = models.HealthDown
+		} else if health.Status == models.HealthUp {
+			health.Status = models.HealthDegraded
+		}
+	}
+
+	return health
+}
+
+// run runs a check within the timeout. A check that does not return in time
+// is reported down and left to finish on its own.
+func (s *Service) run(ctx context.Context, c check) models.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := models.CheckResult{
+		Status:    models.HealthUp,
+		Critical:  c.critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("timed out")
+		}
+
+		res.Status = models.HealthDown
+		res.Error = err.Error()
+		s.log.WarnContext(ctx, "health check failed", slog.String("check", c.name), lg.Err(err))
+	}
+
+	return res
+}
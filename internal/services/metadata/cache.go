@@ -0,0 +1,225 @@
+package metadata
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/client"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/lru"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// CacheStore is the persistent cache tier.
+type CacheStore interface {
+	GetMetadataCache(ctx context.Context, key string) (models.MetadataCacheEntry, error)
+	SaveMetadataCache(ctx context.Context, entry models.MetadataCacheEntry) error
+	ListMetadataCache(ctx context.Context, offset int, limit int) ([]models.MetadataCacheEntry, error)
+	DeleteMetadataCache(ctx context.Context, key string) error
+	PurgeMetadataCache(ctx context.Context) (int64, error)
+}
+
+// Cache answers repeated lookups of the same group and song without asking
+// the providers again. Found details live for the TTL, "not found" answers
+// for the negative TTL; failures are never cached. Entries are looked up in
+// memory first, then in the persistent tier when there is one.
+type Cache struct {
+	log    *slog.Logger
+	next   Provider
+	memory *lru.Cache[string, models.MetadataCacheEntry]
+	store  CacheStore
+	cfg    config.MetadataCache
+}
+
+// NewCache wraps next with a cache. store may be nil to keep the cache in
+// memory only.
+func NewCache(log *slog.Logger, next Provider, store CacheStore, cfg config.MetadataCache) *Cache {
+	return &Cache{
+		log:    log,
+		next:   next,
+		memory: lru.New[string, models.MetadataCacheEntry](cfg.Capacity),
+		store:  store,
+		cfg:    cfg,
+	}
+}
+
+// CacheKey normalises the request so that lookups differing only in case or
+// whitespace share an entry.
+func CacheKey(songReq models.SongRequest) string {
+	return normalise(songReq.Group) + "\n" + normalise(songReq.Name)
+}
+
+func normalise(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+func (c *Cache) GetSongDetail(ctx context.Context, songReq models.SongRequest) (models.SongDetail, error) {
+	const op = "services.metadata.Cache.GetSongDetail"
+
+	key := CacheKey(songReq)
+
+	if entry, ok := c.lookup(ctx, key); ok {
+		c.log.DebugContext(ctx, "metadata cache hit", slog.String("tier", entry.Tier), slog.Bool("found", entry.Found))
+
+		if !entry.Found {
+			return models.SongDetail{}, fmt.Errorf("%s: %w", op, client.ErrSongNotFound)
+		}
+		return *entry.Detail, nil
+	}
+
+	detail, err := c.next.GetSongDetail(ctx, songReq)
+	switch {
+	case err == nil:
+		c.save(ctx, key, songReq, &detail, c.cfg.TTL)
+		return detail, nil
+	case errors.Is(err, client.ErrSongNotFound) && !errors.Is(err, client.ErrUpstreamUnavailable):
+		c.save(ctx, key, songReq, nil, c.cfg.NegativeTTL)
+		return models.SongDetail{}, err
+	default:
+		return models.SongDetail{}, err
+	}
+}
+
+func (c *Cache) lookup(ctx context.Context, key string) (models.MetadataCacheEntry, bool) {
+	if entry, ok := c.memory.Get(key); ok {
+		entry.Tier = models.CacheTierMemory
+		return entry, true
+	}
+
+	if c.store == nil {
+		return models.MetadataCacheEntry{}, false
+	}
+
+	entry, err := c.store.GetMetadataCache(ctx, key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrCacheEntryNotFound) {
+			c.log.ErrorContext(ctx, "error reading metadata cache", lg.Err(err))
+		}
+		return models.MetadataCacheEntry{}, false
+	}
+
+	c.memory.Set(key, entry, time.Until(entry.ExpiresAt))
+
+	return entry, true
+}
+
+func (c *Cache) save(ctx context.Context, key string, songReq models.SongRequest, detail *models.SongDetail, ttl time.Duration) {
+	entry := models.MetadataCacheEntry{
+		Key:       key,
+		Group:     normalise(songReq.Group),
+		Song:      normalise(songReq.Name),
+		Found:     detail != nil,
+		Detail:    detail,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	c.memory.Set(key, entry, ttl)
+
+	if c.store == nil {
+		return
+	}
+
+	if err := c.store.SaveMetadataCache(ctx, entry); err != nil {
+		c.log.ErrorContext(ctx, "error writing metadata cache", lg.Err(err))
+	}
+}
+
+// Inspect returns the cached entry of the song without asking the providers.
+func (c *Cache) Inspect(ctx context.Context, songReq models.SongRequest) (models.MetadataCacheEntry, error) {
+	const op = "services.metadata.Cache.Inspect"
+
+	key := CacheKey(songReq)
+
+	if item, ok := c.memory.Peek(key); ok {
+		item.Value.Tier = models.CacheTierMemory
+		return item.Value, nil
+	}
+
+	if c.store == nil {
+		return models.MetadataCacheEntry{}, storage.ErrCacheEntryNotFound
+	}
+
+	entry, err := c.store.GetMetadataCache(ctx, key)
+	if err != nil {
+		return models.MetadataCacheEntry{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entry, nil
+}
+
+// List returns every live in-memory entry and a page of the persistent tier.
+func (c *Cache) List(ctx context.Context, offset int, limit int) (models.MetadataCacheListing, error) {
+	const op = "services.metadata.Cache.List"
+
+	listing := models.MetadataCacheListing{Memory: []models.MetadataCacheEntry{}}
+
+	for _, item := range c.memory.Items() {
+		item.Value.Tier = models.CacheTierMemory
+		listing.Memory = append(listing.Memory, item.Value)
+	}
+
+	if c.store != nil {
+		entries, err := c.store.ListMetadataCache(ctx, offset, limit)
+		if err != nil {
+			return models.MetadataCacheListing{}, fmt.Errorf("%s: %w", op, err)
+		}
+		listing.Persistent = entries
+	}
+
+	return listing, nil
+}
+
+// Evict removes the entry of the song from both tiers. Only the memory tier
+// of this process is cleared: other replicas keep serving their copy until
+// it expires.
+func (c *Cache) Evict(ctx context.Context, songReq models.SongRequest) error {
+	const op = "services.metadata.Cache.Evict"
+
+	key := CacheKey(songReq)
+	found := c.memory.Delete(key)
+
+	if c.store != nil {
+		err := c.store.DeleteMetadataCache(ctx, key)
+		switch {
+		case err == nil:
+			found = true
+		case !errors.Is(err, storage.ErrCacheEntryNotFound):
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if !found {
+		return storage.ErrCacheEntryNotFound
+	}
+
+	c.log.InfoContext(ctx, "evicted metadata cache entry", slog.String("group", songReq.Group), slog.String("song", songReq.Name))
+	return nil
+}
+
+// Purge empties both tiers and returns the number of entries removed from
+// each. As with Evict, other replicas keep their memory tier until the
+// entries expire.
+func (c *Cache) Purge(ctx context.Context) (models.MetadataCachePurge, error) {
+	const op = "services.metadata.Cache.Purge"
+
+	purge := models.MetadataCachePurge{Memory: int64(c.memory.Purge())}
+
+	if c.store != nil {
+		deleted, err := c.store.PurgeMetadataCache(ctx)
+		if err != nil {
+			return models.MetadataCachePurge{}, fmt.Errorf("%s: %w", op, err)
+		}
+		purge.Persistent = deleted
+	}
+
+	c.log.InfoContext(ctx, "purged metadata cache",
+		slog.Int64("memory", purge.Memory),
+		slog.Int64("persistent", purge.Persistent),
+	)
+	return purge, nil
+}
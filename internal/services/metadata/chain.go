@@ -0,0 +1,206 @@
+package metadata
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/client"
+	"effectivemobiletesttask/internal/domain/models"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+const (
+	FieldReleaseDate = "releaseDate"
+	FieldText        = "text"
+	FieldLink        = "link"
+)
+
+var fields = []string{FieldReleaseDate, FieldText, FieldLink}
+
+func knownField(field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasField(detail models.SongDetail, field string) bool {
+	switch field {
+	case FieldReleaseDate:
+		return !detail.ReleaseDate.IsZero()
+	case FieldText:
+		return detail.Text != ""
+	case FieldLink:
+		return detail.Link != ""
+	}
+
+	return false
+}
+
+func copyField(dst *models.SongDetail, src models.SongDetail, field string) {
+	switch field {
+	case FieldReleaseDate:
+		dst.ReleaseDate = src.ReleaseDate
+	case FieldText:
+		dst.Text = src.Text
+	case FieldLink:
+		dst.Link = src.Link
+	}
+}
+
+type namedProvider struct {
+	name     string
+	provider Provider
+}
+
+type result struct {
+	detail models.SongDetail
+	err    error
+}
+
+// Chain asks its providers in priority order and merges their answers field
+// by field: every field is taken from the first provider in the field's merge
+// order that returned it. Providers are only asked while some field still
+// depends on them.
+type Chain struct {
+	log       *slog.Logger
+	providers []namedProvider
+	order     map[string][]string
+}
+
+func newChain(log *slog.Logger, providers []namedProvider, merge map[string][]string) *Chain {
+	order := make(map[string][]string, len(fields))
+
+	for _, field := range fields {
+		if names, ok := merge[field]; ok {
+			order[field] = names
+			continue
+		}
+
+		for _, p := range providers {
+			order[field] = append(order[field], p.name)
+		}
+	}
+
+	return &Chain{
+		log:       log,
+		providers: providers,
+		order:     order,
+	}
+}
+
+// Pingers returns the providers of the chain that can be pinged, by name.
+func (c *Chain) Pingers() map[string]Pinger {
+	pingers := make(map[string]Pinger)
+
+	for _, p := range c.providers {
+		if pinger, ok := p.provider.(Pinger); ok {
+			pingers[p.name] = pinger
+		}
+	}
+
+	return pingers
+}
+
+func (c *Chain) GetSongDetail(ctx context.Context, songReq models.SongRequest) (models.SongDetail, error) {
+	const op = "services.metadata.GetSongDetail"
+
+	results := make(map[string]result, len(c.providers))
+
+	for _, p := range c.providers {
+		if !c.needs(p.name, results) {
+			continue
+		}
+
+		detail, err := p.provider.GetSongDetail(ctx, songReq)
+		if err != nil {
+			c.log.DebugContext(ctx, "metadata provider failed", slog.String("provider", p.name), lg.Err(err))
+			err = fmt.Errorf("%s: %w", p.name, err)
+		}
+		results[p.name] = result{detail: detail, err: err}
+	}
+
+	var merged models.SongDetail
+	var errs []error
+	found := false
+
+	for _, field := range fields {
+		for _, name := range c.order[field] {
+			res, ok := results[name]
+			if !ok || res.err != nil {
+				continue
+			}
+			if hasField(res.detail, field) {
+				copyField(&merged, res.detail, field)
+				found = true
+				break
+			}
+		}
+	}
+
+	for _, p := range c.providers {
+		if res, ok := results[p.name]; ok && res.err != nil {
+			errs = append(errs, res.err)
+		}
+	}
+
+	if !found && len(errs) > 0 {
+		return models.SongDetail{}, fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+
+	// A field was left empty because a provider it depends on is down: report
+	// it so the enrichment is retried instead of saving a partial result.
+	for _, field := range fields {
+		if !hasField(merged, field) && c.dependsOnFailed(field, results) {
+			return models.SongDetail{}, fmt.Errorf("%s: %s is incomplete: %w", op, field, errors.Join(errs...))
+		}
+	}
+
+	return merged, nil
+}
+
+// needs reports whether the provider is part of the merge order of a field
+// that is not resolved yet. A field is resolved once a provider returned it
+// and every provider preferred over that one has already been asked.
+func (c *Chain) needs(name string, results map[string]result) bool {
+	for _, field := range fields {
+		resolved := false
+
+		for _, candidate := range c.order[field] {
+			res, asked := results[candidate]
+			if !asked {
+				break
+			}
+			if res.err == nil && hasField(res.detail, field) {
+				resolved = true
+				break
+			}
+		}
+
+		if resolved {
+			continue
+		}
+
+		for _, candidate := range c.order[field] {
+			if candidate == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *Chain) dependsOnFailed(field string, results map[string]result) bool {
+	for _, name := range c.order[field] {
+		if res, ok := results[name]; ok && errors.Is(res.err, client.ErrUpstreamUnavailable) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,164 @@
+package metadata
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/client"
+	"effectivemobiletesttask/internal/domain/models"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var (
+	full = models.SongDetail{
+		ReleaseDate: time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC),
+		Text:        "Ooh baby, don't you know I suffer?",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+	other = models.SongDetail{
+		ReleaseDate: time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC),
+		Text:        "other text",
+		Link:        "https://example.com/other",
+	}
+)
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// counted records how many times the chain asked its provider.
+type counted struct {
+	Provider
+	calls int
+}
+
+func (p *counted) GetSongDetail(ctx context.Context, songReq models.SongRequest) (models.SongDetail, error) {
+	p.calls++
+	return p.Provider.GetSongDetail(ctx, songReq)
+}
+
+func TestChainGetSongDetail(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []Provider
+		merge     map[string][]string
+		want      models.SongDetail
+		wantErr   []error
+		wantCalls []int
+	}{
+		{
+			name:      "first provider fills every field",
+			providers: []Provider{NewStubProvider(full), NewStubProvider(other)},
+			want:      full,
+			wantCalls: []int{1, 0},
+		},
+		{
+			name: "partial answer is completed by a lower priority provider",
+			providers: []Provider{
+				NewStubProvider(models.SongDetail{Text: full.Text}),
+				NewStubProvider(other),
+			},
+			want:      models.SongDetail{ReleaseDate: other.ReleaseDate, Text: full.Text, Link: other.Link},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "merge order overrides the priority of a field",
+			providers: []Provider{NewStubProvider(full), NewStubProvider(other)},
+			merge:     map[string][]string{FieldText: {"p1", "p0"}},
+			want:      models.SongDetail{ReleaseDate: full.ReleaseDate, Text: other.Text, Link: full.Link},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name: "provider without the song is skipped",
+			providers: []Provider{
+				NewFailingStubProvider(client.ErrSongNotFound),
+				NewStubProvider(other),
+			},
+			want:      other,
+			wantCalls: []int{1, 1},
+		},
+		{
+			name: "unavailable provider is skipped when the others fill every field",
+			providers: []Provider{
+				NewFailingStubProvider(client.ErrUpstreamUnavailable),
+				NewStubProvider(other),
+			},
+			want:      other,
+			wantCalls: []int{1, 1},
+		},
+		{
+			name: "field left empty behind an unavailable provider",
+			providers: []Provider{
+				NewFailingStubProvider(client.ErrUpstreamUnavailable),
+				NewStubProvider(models.SongDetail{Text: other.Text}),
+			},
+			wantErr:   []error{client.ErrUpstreamUnavailable},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name: "field left empty because no provider knows it",
+			providers: []Provider{
+				NewFailingStubProvider(client.ErrSongNotFound),
+				NewStubProvider(models.SongDetail{Text: other.Text}),
+			},
+			want:      models.SongDetail{Text: other.Text},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name: "every provider fails",
+			providers: []Provider{
+				NewFailingStubProvider(client.ErrSongNotFound),
+				NewFailingStubProvider(client.ErrUpstreamUnavailable),
+			},
+			wantErr:   []error{client.ErrSongNotFound, client.ErrUpstreamUnavailable},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "no provider knows anything",
+			providers: []Provider{NewStubProvider(models.SongDetail{}), NewStubProvider(models.SongDetail{})},
+			want:      models.SongDetail{},
+			wantCalls: []int{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]namedProvider, len(tt.providers))
+			counters := make([]*counted, len(tt.providers))
+			for i, p := range tt.providers {
+				counters[i] = &counted{Provider: p}
+				providers[i] = namedProvider{name: "p" + strconv.Itoa(i), provider: counters[i]}
+			}
+
+			chain := newChain(discard(), providers, tt.merge)
+
+			got, err := chain.GetSongDetail(context.Background(), models.SongRequest{Group: "Muse", Name: "Supermassive Black Hole"})
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("GetSongDetail() error = nil, want %v", tt.wantErr)
+				}
+				for _, want := range tt.wantErr {
+					if !errors.Is(err, want) {
+						t.Errorf("GetSongDetail() error = %v, want %v", err, want)
+					}
+				}
+			} else if err != nil {
+				t.Fatalf("GetSongDetail() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSongDetail() = %+v, want %+v", got, tt.want)
+			}
+
+			for i, c := range counters {
+				if c.calls != tt.wantCalls[i] {
+					t.Errorf("provider %d asked %d times, want %d", i, c.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
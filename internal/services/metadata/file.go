@@ -0,0 +1,85 @@
+package metadata
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/client"
+	"effectivemobiletesttask/internal/domain/models"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const dateLayout = "2006-01-02"
+
+type catalogueEntry struct {
+	Group       string `json:"group" yaml:"group"`
+	Song        string `json:"song" yaml:"song"`
+	ReleaseDate string `json:"releaseDate" yaml:"releaseDate"`
+	Text        string `json:"text" yaml:"text"`
+	Link        string `json:"link" yaml:"link"`
+}
+
+// FileProvider serves song details from a catalogue on disk. The catalogue
+// is a JSON or YAML list of entries (group, song, releaseDate, text, link)
+// and is read once, when the provider is created.
+type FileProvider struct {
+	songs map[string]models.SongDetail
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	const op = "services.metadata.NewFileProvider"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var entries []catalogueEntry
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &entries)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &entries)
+	default:
+		return nil, fmt.Errorf("%s: %w: unsupported catalogue format %q", op, ErrInvalidProvider, filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songs := make(map[string]models.SongDetail, len(entries))
+
+	for i, entry := range entries {
+		detail := models.SongDetail{Text: entry.Text, Link: entry.Link}
+
+		if entry.ReleaseDate != "" {
+			detail.ReleaseDate, err = time.Parse(dateLayout, entry.ReleaseDate)
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %d: invalid release date: %w", op, i, err)
+			}
+		}
+
+		songs[catalogueKey(entry.Group, entry.Song)] = detail
+	}
+
+	return &FileProvider{songs: songs}, nil
+}
+
+func (p *FileProvider) GetSongDetail(_ context.Context, songReq models.SongRequest) (models.SongDetail, error) {
+	detail, ok := p.songs[catalogueKey(songReq.Group, songReq.Name)]
+	if !ok {
+		return models.SongDetail{}, client.ErrSongNotFound
+	}
+
+	return detail, nil
+}
+
+func catalogueKey(group string, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
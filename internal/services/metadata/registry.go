@@ -0,0 +1,134 @@
+package metadata
+
+import (
+	"context"
+	songclient "effectivemobiletesttask/internal/client/song"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/domain/models"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const (
+	KindAPI  = "api"
+	KindFile = "file"
+	KindStub = "stub"
+)
+
+var (
+	ErrUnknownKind     = errors.New("unknown metadata provider kind")
+	ErrInvalidProvider = errors.New("invalid metadata provider")
+	ErrInvalidMerge    = errors.New("invalid metadata merge policy")
+)
+
+// Provider supplies song details from a single source. Implementations
+// report a missing song with client.ErrSongNotFound and transient failures
+// with client.ErrUpstreamUnavailable.
+type Provider interface {
+	GetSongDetail(ctx context.Context, songReq models.SongRequest) (models.SongDetail, error)
+}
+
+// Pinger is implemented by providers that can check that their source is
+// reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Factory builds a provider from its configuration entry.
+type Factory func(log *slog.Logger, cfg config.MetadataProvider) (Provider, error)
+
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry returns a registry with the built-in provider kinds: the song
+// info API, a local catalogue file and a static stub.
+func NewRegistry(api config.APIClient) *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+
+	r.Register(KindAPI, func(log *slog.Logger, _ config.MetadataProvider) (Provider, error) {
+		return songclient.NewClient(log, api), nil
+	})
+	r.Register(KindFile, func(_ *slog.Logger, cfg config.MetadataProvider) (Provider, error) {
+		return NewFileProvider(cfg.Path)
+	})
+	r.Register(KindStub, func(_ *slog.Logger, cfg config.MetadataProvider) (Provider, error) {
+		var detail models.SongDetail
+
+		if cfg.Stub.ReleaseDate != "" {
+			releaseDate, err := time.Parse(dateLayout, cfg.Stub.ReleaseDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stub release date: %w", err)
+			}
+			detail.ReleaseDate = releaseDate
+		}
+		detail.Text = cfg.Stub.Text
+		detail.Link = cfg.Stub.Link
+
+		return NewStubProvider(detail), nil
+	})
+
+	return r
+}
+
+// Register adds or replaces the factory of a provider kind.
+func (r *Registry) Register(kind string, factory Factory) {
+	r.factories[kind] = factory
+}
+
+// Build creates the configured providers and chains them. Without any
+// configured provider the chain consists of the song info API alone.
+func (r *Registry) Build(log *slog.Logger, cfg config.Metadata) (*Chain, error) {
+	const op = "services.metadata.Build"
+
+	entries := cfg.Providers
+	if len(entries) == 0 {
+		entries = []config.MetadataProvider{{Name: KindAPI, Kind: KindAPI}}
+	}
+
+	entries = append([]config.MetadataProvider(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Priority < entries[j].Priority
+	})
+
+	providers := make([]namedProvider, 0, len(entries))
+	names := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("%s: %w: name is required", op, ErrInvalidProvider)
+		}
+		if names[entry.Name] {
+			return nil, fmt.Errorf("%s: %w: duplicate name %q", op, ErrInvalidProvider, entry.Name)
+		}
+		names[entry.Name] = true
+
+		factory, ok := r.factories[entry.Kind]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownKind, entry.Kind)
+		}
+
+		provider, err := factory(log.With(slog.String("provider", entry.Name)), entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: provider %q: %w", op, entry.Name, err)
+		}
+
+		providers = append(providers, namedProvider{name: entry.Name, provider: provider})
+	}
+
+	for field, order := range cfg.Merge {
+		if !knownField(field) {
+			return nil, fmt.Errorf("%s: %w: unknown field %q", op, ErrInvalidMerge, field)
+		}
+		for _, name := range order {
+			if !names[name] {
+				return nil, fmt.Errorf("%s: %w: unknown provider %q for %q", op, ErrInvalidMerge, name, field)
+			}
+		}
+	}
+
+	return newChain(log, providers, cfg.Merge), nil
+}
@@ -0,0 +1,107 @@
+package metadata
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/domain/models"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func stub(name string, priority int, text string) config.MetadataProvider {
+	return config.MetadataProvider{Name: name, Kind: KindStub, Priority: priority, Stub: config.StubMetadata{Text: text}}
+}
+
+func TestRegistryBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Metadata
+		wantText string
+		wantErr  error
+	}{
+		{
+			name:     "providers are asked by priority",
+			cfg:      config.Metadata{Providers: []config.MetadataProvider{stub("late", 2, "late"), stub("early", 1, "early")}},
+			wantText: "early",
+		},
+		{
+			name: "merge order",
+			cfg: config.Metadata{
+				Providers: []config.MetadataProvider{stub("late", 2, "late"), stub("early", 1, "early")},
+				Merge:     map[string][]string{FieldText: {"late"}},
+			},
+			wantText: "late",
+		},
+		{
+			name:    "unknown kind",
+			cfg:     config.Metadata{Providers: []config.MetadataProvider{{Name: "p", Kind: "ftp"}}},
+			wantErr: ErrUnknownKind,
+		},
+		{
+			name:    "duplicate name",
+			cfg:     config.Metadata{Providers: []config.MetadataProvider{stub("p", 1, "a"), stub("p", 2, "b")}},
+			wantErr: ErrInvalidProvider,
+		},
+		{
+			name:    "missing name",
+			cfg:     config.Metadata{Providers: []config.MetadataProvider{stub("", 1, "a")}},
+			wantErr: ErrInvalidProvider,
+		},
+		{
+			name: "merge of an unknown field",
+			cfg: config.Metadata{
+				Providers: []config.MetadataProvider{stub("p", 1, "a")},
+				Merge:     map[string][]string{"lyrics": {"p"}},
+			},
+			wantErr: ErrInvalidMerge,
+		},
+		{
+			name: "merge from an unknown provider",
+			cfg: config.Metadata{
+				Providers: []config.MetadataProvider{stub("p", 1, "a")},
+				Merge:     map[string][]string{FieldText: {"q"}},
+			},
+			wantErr: ErrInvalidMerge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := NewRegistry(config.APIClient{}).Build(discard(), tt.cfg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Build() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			detail, err := chain.GetSongDetail(context.Background(), models.SongRequest{Group: "Muse", Name: "Supermassive Black Hole"})
+			if err != nil {
+				t.Fatalf("GetSongDetail() error = %v", err)
+			}
+			if detail.Text != tt.wantText {
+				t.Errorf("GetSongDetail() text = %q, want %q", detail.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry(config.APIClient{})
+	r.Register("custom", func(_ *slog.Logger, _ config.MetadataProvider) (Provider, error) {
+		return NewStubProvider(full), nil
+	})
+
+	chain, err := r.Build(discard(), config.Metadata{Providers: []config.MetadataProvider{{Name: "c", Kind: "custom"}}})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if detail, err := chain.GetSongDetail(context.Background(), models.SongRequest{}); err != nil || detail != full {
+		t.Errorf("GetSongDetail() = %+v, %v, want %+v", detail, err, full)
+	}
+}
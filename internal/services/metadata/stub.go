@@ -0,0 +1,30 @@
+package metadata
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+)
+
+// StubProvider returns the same details for every song. It stands in for the
+// real providers in tests and local setups.
+type StubProvider struct {
+	detail models.SongDetail
+	err    error
+}
+
+func NewStubProvider(detail models.SongDetail) *StubProvider {
+	return &StubProvider{detail: detail}
+}
+
+// NewFailingStubProvider returns a stub that fails every request with err.
+func NewFailingStubProvider(err error) *StubProvider {
+	return &StubProvider{err: err}
+}
+
+func (p *StubProvider) GetSongDetail(_ context.Context, _ models.SongRequest) (models.SongDetail, error) {
+	if p.err != nil {
+		return models.SongDetail{}, p.err
+	}
+
+	return p.detail, nil
+}
@@ -0,0 +1,41 @@
+package policy
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/services/auth"
+)
+
+// KeyService checks permissions before managing API keys.
+type KeyService struct {
+	authz *Authorizer
+	next  *auth.Service
+}
+
+func NewKeyService(authz *Authorizer, next *auth.Service) *KeyService {
+	return &KeyService{authz: authz, next: next}
+}
+
+func (s *KeyService) CreateAPIKey(ctx context.Context, name string, roles []string) (models.CreatedAPIKey, error) {
+	if err := s.authz.Check(ctx, PermKeysManage, "CreateAPIKey"); err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+
+	return s.next.CreateAPIKey(ctx, name, roles)
+}
+
+func (s *KeyService) ListAPIKeys(ctx context.Context, offset int, limit int) ([]models.APIKey, error) {
+	if err := s.authz.Check(ctx, PermKeysManage, "ListAPIKeys"); err != nil {
+		return nil, err
+	}
+
+	return s.next.ListAPIKeys(ctx, offset, limit)
+}
+
+func (s *KeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	if err := s.authz.Check(ctx, PermKeysManage, "RevokeAPIKey"); err != nil {
+		return err
+	}
+
+	return s.next.RevokeAPIKey(ctx, id)
+}
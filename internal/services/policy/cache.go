@@ -0,0 +1,50 @@
+package policy
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/services/metadata"
+)
+
+// MetadataCache checks permissions before inspecting or changing the
+// metadata cache.
+type MetadataCache struct {
+	authz *Authorizer
+	next  *metadata.Cache
+}
+
+func NewMetadataCache(authz *Authorizer, next *metadata.Cache) *MetadataCache {
+	return &MetadataCache{authz: authz, next: next}
+}
+
+func (c *MetadataCache) Inspect(ctx context.Context, songReq models.SongRequest) (models.MetadataCacheEntry, error) {
+	if err := c.authz.Check(ctx, PermCacheManage, "InspectMetadataCache"); err != nil {
+		return models.MetadataCacheEntry{}, err
+	}
+
+	return c.next.Inspect(ctx, songReq)
+}
+
+func (c *MetadataCache) List(ctx context.Context, offset int, limit int) (models.MetadataCacheListing, error) {
+	if err := c.authz.Check(ctx, PermCacheManage, "ListMetadataCache"); err != nil {
+		return models.MetadataCacheListing{}, err
+	}
+
+	return c.next.List(ctx, offset, limit)
+}
+
+func (c *MetadataCache) Evict(ctx context.Context, songReq models.SongRequest) error {
+	if err := c.authz.Check(ctx, PermCacheManage, "EvictMetadataCache"); err != nil {
+		return err
+	}
+
+	return c.next.Evict(ctx, songReq)
+}
+
+func (c *MetadataCache) Purge(ctx context.Context) (models.MetadataCachePurge, error) {
+	if err := c.authz.Check(ctx, PermCacheManage, "PurgeMetadataCache"); err != nil {
+		return models.MetadataCachePurge{}, err
+	}
+
+	return c.next.Purge(ctx)
+}
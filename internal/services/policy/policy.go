@@ -0,0 +1,193 @@
+// Package policy decides which operations a principal may perform. Roles
+// grant permissions, subjects are granted roles, and the decorators in this
+// package check the permission of every operation before calling the
+// service they wrap.
+package policy
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/domain/actor"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/domain/principal"
+	"effectivemobiletesttask/internal/services"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	PermSongsRead    = "songs:read"
+	PermSongsWrite   = "songs:write"
+	PermSongsDelete  = "songs:delete"
+	PermGroupsManage = "groups:manage"
+	PermTrashManage  = "trash:manage"
+	PermKeysManage   = "keys:manage"
+	PermCacheManage  = "cache:manage"
+	PermAuthzRead    = "authz:read"
+	// PermAll grants every permission.
+	PermAll = "*"
+)
+
+var permissions = map[string]bool{
+	PermSongsRead:    true,
+	PermSongsWrite:   true,
+	PermSongsDelete:  true,
+	PermGroupsManage: true,
+	PermTrashManage:  true,
+	PermKeysManage:   true,
+	PermCacheManage:  true,
+	PermAuthzRead:    true,
+	PermAll:          true,
+}
+
+// DefaultRoles are the built-in roles: viewers read songs and lyrics,
+// editors also create and change them, admins may do everything.
+func DefaultRoles() map[string][]string {
+	return map[string][]string{
+		principal.RoleViewer: {PermSongsRead},
+		principal.RoleEditor: {PermSongsRead, PermSongsWrite},
+		principal.RoleAdmin:  {PermAll},
+	}
+}
+
+type Store interface {
+	SyncRoles(ctx context.Context, roles map[string][]string, grants map[string][]string) error
+	GetRoles(ctx context.Context) (map[string][]string, error)
+	RecordDenial(ctx context.Context, denial models.Denial) error
+	ListDenials(ctx context.Context, offset int, limit int) ([]models.Denial, error)
+	PurgeDenials(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Authorizer struct {
+	log       *slog.Logger
+	store     Store
+	enabled   bool
+	roles     map[string]map[string]bool
+	retention time.Duration
+}
+
+// New writes the configured roles and grants to the store and loads the
+// permissions of every role. A disabled authorizer permits everything, for
+// when authentication is off and requests carry no principal.
+func New(ctx context.Context, log *slog.Logger, store Store, cfg config.Authz, enabled bool) (*Authorizer, error) {
+	const op = "services.policy.New"
+
+	roles := DefaultRoles()
+	for role, perms := range cfg.Roles {
+		roles[role] = perms
+	}
+
+	for role, perms := range roles {
+		for _, perm := range perms {
+			if !permissions[perm] {
+				return nil, fmt.Errorf("%s: role %q: unknown permission %q", op, role, perm)
+			}
+		}
+	}
+
+	for subject := range cfg.Grants {
+		if !principal.ValidGrantSubject(subject) {
+			return nil, fmt.Errorf(`%s: grant subject %q must be "key:<id>" or "jwt:<sub>"`, op, subject)
+		}
+	}
+
+	if err := store.SyncRoles(ctx, roles, cfg.Grants); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stored, err := store.GetRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a := &Authorizer{
+		log:       log,
+		store:     store,
+		enabled:   enabled,
+		roles:     make(map[string]map[string]bool, len(stored)),
+		retention: cfg.DenialRetention,
+	}
+
+	for role, perms := range stored {
+		a.roles[role] = make(map[string]bool, len(perms))
+		for _, perm := range perms {
+			a.roles[role][perm] = true
+		}
+	}
+
+	return a, nil
+}
+
+// Allowed reports whether p has the permission through any of its roles.
+func (a *Authorizer) Allowed(p principal.Principal, permission string) bool {
+	for _, role := range p.Roles {
+		perms := a.roles[role]
+		if perms[permission] || perms[PermAll] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check returns services.ErrForbidden when the principal of ctx lacks the
+// permission needed for operation. Denials are logged and recorded.
+func (a *Authorizer) Check(ctx context.Context, permission string, operation string) error {
+	if !a.enabled {
+		return nil
+	}
+
+	p, _ := principal.From(ctx)
+	if a.Allowed(p, permission) {
+		return nil
+	}
+
+	subject := actor.From(ctx)
+	a.log.WarnContext(ctx, "operation denied",
+		slog.String("operation", operation),
+		slog.String("permission", permission),
+		slog.Any("roles", p.Roles),
+	)
+
+	denial := models.Denial{Subject: subject, Permission: permission, Operation: operation}
+	if err := a.store.RecordDenial(ctx, denial); err != nil {
+		a.log.ErrorContext(ctx, "error recording denial", lg.Err(err))
+	}
+
+	return services.ErrForbidden.Withf("%s requires the %s permission", operation, permission)
+}
+
+// ListDenials returns the recorded denials, newest first.
+func (a *Authorizer) ListDenials(ctx context.Context, offset int, limit int) ([]models.Denial, error) {
+	const op = "services.policy.ListDenials"
+
+	if err := a.Check(ctx, PermAuthzRead, "ListDenials"); err != nil {
+		return nil, err
+	}
+
+	denials, err := a.store.ListDenials(ctx, offset, limit)
+	if err != nil {
+		a.log.ErrorContext(ctx, "error listing denials", lg.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return denials, nil
+}
+
+// PurgeDenials removes the denials recorded longer than the retention ago.
+func (a *Authorizer) PurgeDenials(ctx context.Context) (int64, error) {
+	const op = "services.policy.PurgeDenials"
+
+	purged, err := a.store.PurgeDenials(ctx, time.Now().Add(-a.retention))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if purged > 0 {
+		a.log.InfoContext(ctx, "purged denials", slog.Int64("denials", purged))
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,281 @@
+package policy
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/services/song"
+)
+
+// SongService checks permissions before calling the song service. It serves
+// the song, group and trash handlers. Reading deleted songs and groups needs
+// the trash permission.
+type SongService struct {
+	authz *Authorizer
+	next  *song.Service
+}
+
+func NewSongService(authz *Authorizer, next *song.Service) *SongService {
+	return &SongService{authz: authz, next: next}
+}
+
+func (s *SongService) readPermission(includeDeleted bool) string {
+	if includeDeleted {
+		return PermTrashManage
+	}
+
+	return PermSongsRead
+}
+
+func (s *SongService) CreateSong(ctx context.Context, songReq models.SongRequest) (int64, error) {
+	if err := s.authz.Check(ctx, PermSongsWrite, "CreateSong"); err != nil {
+		return 0, err
+	}
+
+	return s.next.CreateSong(ctx, songReq)
+}
+
+func (s *SongService) GetSongByID(ctx context.Context, id int64, includeDeleted bool) (models.SongResponse, error) {
+	if err := s.authz.Check(ctx, s.readPermission(includeDeleted), "GetSongByID"); err != nil {
+		return models.SongResponse{}, err
+	}
+
+	return s.next.GetSongByID(ctx, id, includeDeleted)
+}
+
+func (s *SongService) GetSongByName(ctx context.Context, songName string) (models.SongResponse, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "GetSongByName"); err != nil {
+		return models.SongResponse{}, err
+	}
+
+	return s.next.GetSongByName(ctx, songName)
+}
+
+func (s *SongService) GetSongTextByID(ctx context.Context, id int64, verses models.VerseRange) (models.SongText, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "GetSongTextByID"); err != nil {
+		return models.SongText{}, err
+	}
+
+	return s.next.GetSongTextByID(ctx, id, verses)
+}
+
+func (s *SongService) GetSongTextByName(
+	ctx context.Context,
+	songName string,
+	verses models.VerseRange,
+) (models.SongText, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "GetSongTextByName"); err != nil {
+		return models.SongText{}, err
+	}
+
+	return s.next.GetSongTextByName(ctx, songName, verses)
+}
+
+func (s *SongService) UpdateSong(ctx context.Context, id int64, newSong models.SongResponse) (models.SongResponse, error) {
+	if err := s.authz.Check(ctx, PermSongsWrite, "UpdateSong"); err != nil {
+		return models.SongResponse{}, err
+	}
+
+	return s.next.UpdateSong(ctx, id, newSong)
+}
+
+func (s *SongService) PatchSong(
+	ctx context.Context,
+	id int64,
+	patch func(current models.SongResponse) (models.SongResponse, error),
+) (models.SongResponse, error) {
+	if err := s.authz.Check(ctx, PermSongsWrite, "PatchSong"); err != nil {
+		return models.SongResponse{}, err
+	}
+
+	return s.next.PatchSong(ctx, id, patch)
+}
+
+func (s *SongService) DeleteSong(ctx context.Context, id int64, version int64) error {
+	if err := s.authz.Check(ctx, PermSongsDelete, "DeleteSong"); err != nil {
+		return err
+	}
+
+	return s.next.DeleteSong(ctx, id, version)
+}
+
+func (s *SongService) GetAllSongs(
+	ctx context.Context,
+	filter models.SongFilter,
+	page models.Pagination,
+) (models.SongPage, error) {
+	if err := s.authz.Check(ctx, s.readPermission(filter.IncludeDeleted), "GetAllSongs"); err != nil {
+		return models.SongPage{}, err
+	}
+
+	return s.next.GetAllSongs(ctx, filter, page)
+}
+
+func (s *SongService) SearchSongs(ctx context.Context, search models.SongSearch) ([]models.SongSearchHit, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "SearchSongs"); err != nil {
+		return nil, err
+	}
+
+	return s.next.SearchSongs(ctx, search)
+}
+
+func (s *SongService) GetLyrics(ctx context.Context, id int64) (models.Lyrics, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "GetLyrics"); err != nil {
+		return models.Lyrics{}, err
+	}
+
+	return s.next.GetLyrics(ctx, id)
+}
+
+func (s *SongService) UpdateLyrics(ctx context.Context, id int64, sections []models.LyricsSection) (models.Lyrics, error) {
+	if err := s.authz.Check(ctx, PermSongsWrite, "UpdateLyrics"); err != nil {
+		return models.Lyrics{}, err
+	}
+
+	return s.next.UpdateLyrics(ctx, id, sections)
+}
+
+func (s *SongService) ExportLyrics(ctx context.Context, id int64, format string) (string, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "ExportLyrics"); err != nil {
+		return "", err
+	}
+
+	return s.next.ExportLyrics(ctx, id, format)
+}
+
+func (s *SongService) ImportLyrics(ctx context.Context, id int64, format string, data string) (models.Lyrics, error) {
+	if err := s.authz.Check(ctx, PermSongsWrite, "ImportLyrics"); err != nil {
+		return models.Lyrics{}, err
+	}
+
+	return s.next.ImportLyrics(ctx, id, format, data)
+}
+
+func (s *SongService) GetEnrichment(ctx context.Context, id int64) (models.Enrichment, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "GetEnrichment"); err != nil {
+		return models.Enrichment{}, err
+	}
+
+	return s.next.GetEnrichment(ctx, id)
+}
+
+func (s *SongService) Reenrich(ctx context.Context, id int64) (models.Enrichment, error) {
+	if err := s.authz.Check(ctx, PermSongsWrite, "Reenrich"); err != nil {
+		return models.Enrichment{}, err
+	}
+
+	return s.next.Reenrich(ctx, id)
+}
+
+func (s *SongService) ListSongRevisions(ctx context.Context, id int64, offset int, limit int) ([]models.SongRevision, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "ListSongRevisions"); err != nil {
+		return nil, err
+	}
+
+	return s.next.ListSongRevisions(ctx, id, offset, limit)
+}
+
+func (s *SongService) GetSongRevision(ctx context.Context, id int64, revision int64) (models.SongRevision, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "GetSongRevision"); err != nil {
+		return models.SongRevision{}, err
+	}
+
+	return s.next.GetSongRevision(ctx, id, revision)
+}
+
+func (s *SongService) DiffSongRevisions(ctx context.Context, id int64, from int64, to int64) (models.SongRevisionDiff, error) {
+	if err := s.authz.Check(ctx, PermSongsRead, "DiffSongRevisions"); err != nil {
+		return models.SongRevisionDiff{}, err
+	}
+
+	return s.next.DiffSongRevisions(ctx, id, from, to)
+}
+
+func (s *SongService) RestoreSongRevision(
+	ctx context.Context,
+	id int64,
+	revision int64,
+	version int64,
+) (models.SongResponse, error) {
+	if err := s.authz.Check(ctx, PermSongsWrite, "RestoreSongRevision"); err != nil {
+		return models.SongResponse{}, err
+	}
+
+	return s.next.RestoreSongRevision(ctx, id, revision, version)
+}
+
+func (s *SongService) CreateGroup(ctx context.Context, groupName string) (int64, error) {
+	if err := s.authz.Check(ctx, PermGroupsManage, "CreateGroup"); err != nil {
+		return 0, err
+	}
+
+	return s.next.CreateGroup(ctx, groupName)
+}
+
+func (s *SongService) GetGroupByID(ctx context.Context, id int64, includeDeleted bool) (models.GroupResponse, error) {
+	if err := s.authz.Check(ctx, s.readPermission(includeDeleted), "GetGroupByID"); err != nil {
+		return models.GroupResponse{}, err
+	}
+
+	return s.next.GetGroupByID(ctx, id, includeDeleted)
+}
+
+func (s *SongService) GetAllGroups(
+	ctx context.Context,
+	filter models.GroupFilter,
+	offset int,
+	limit int,
+) ([]models.GroupResponse, error) {
+	if err := s.authz.Check(ctx, s.readPermission(filter.IncludeDeleted), "GetAllGroups"); err != nil {
+		return nil, err
+	}
+
+	return s.next.GetAllGroups(ctx, filter, offset, limit)
+}
+
+func (s *SongService) RenameGroup(ctx context.Context, id int64, groupName string) (models.GroupResponse, error) {
+	if err := s.authz.Check(ctx, PermGroupsManage, "RenameGroup"); err != nil {
+		return models.GroupResponse{}, err
+	}
+
+	return s.next.RenameGroup(ctx, id, groupName)
+}
+
+func (s *SongService) MergeGroups(ctx context.Context, sourceID int64, targetID int64) (models.GroupResponse, error) {
+	if err := s.authz.Check(ctx, PermGroupsManage, "MergeGroups"); err != nil {
+		return models.GroupResponse{}, err
+	}
+
+	return s.next.MergeGroups(ctx, sourceID, targetID)
+}
+
+func (s *SongService) DeleteGroup(ctx context.Context, id int64, cascade bool) error {
+	if err := s.authz.Check(ctx, PermGroupsManage, "DeleteGroup"); err != nil {
+		return err
+	}
+
+	return s.next.DeleteGroup(ctx, id, cascade)
+}
+
+func (s *SongService) ListTrash(ctx context.Context, offset int, limit int) ([]models.TrashItem, error) {
+	if err := s.authz.Check(ctx, PermTrashManage, "ListTrash"); err != nil {
+		return nil, err
+	}
+
+	return s.next.ListTrash(ctx, offset, limit)
+}
+
+func (s *SongService) RestoreSong(ctx context.Context, id int64) (models.SongResponse, error) {
+	if err := s.authz.Check(ctx, PermTrashManage, "RestoreSong"); err != nil {
+		return models.SongResponse{}, err
+	}
+
+	return s.next.RestoreSong(ctx, id)
+}
+
+func (s *SongService) RestoreGroup(ctx context.Context, id int64) (models.GroupResponse, error) {
+	if err := s.authz.Check(ctx, PermTrashManage, "RestoreGroup"); err != nil {
+		return models.GroupResponse{}, err
+	}
+
+	return s.next.RestoreGroup(ctx, id)
+}
@@ -1,9 +1,16 @@
 package services
 
 import (
-	"errors"
+	"effectivemobiletesttask/internal/domain/errs"
 )
 
 var (
-	ErrFieldIsRequired = errors.New("field is required")
+	ErrFieldIsRequired = errs.New(errs.KindValidation, "field_required", "field is required")
+	ErrMergeIntoItself = errs.New(errs.KindValidation, "group_merge_into_itself", "group cannot be merged into itself")
+	ErrInvalidCursor   = errs.New(errs.KindValidation, "invalid_cursor", "invalid cursor")
+	ErrVerseOutOfRange = errs.New(errs.KindOutOfRange, "verse_out_of_range", "verse is out of range")
+
+	ErrInvalidAPIKey = errs.New(errs.KindUnauthenticated, "invalid_api_key", "API key is invalid or revoked")
+	ErrInvalidToken  = errs.New(errs.KindUnauthenticated, "invalid_token", "bearer token is invalid")
+	ErrForbidden     = errs.New(errs.KindForbidden, "forbidden", "operation is not permitted")
 )
@@ -0,0 +1,124 @@
+package song
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/services"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// withTieBreaker appends the song id to the sort keys so that the ordering is
+// total and a keyset cursor always points at exactly one row.
+func withTieBreaker(keys []models.SortKey) []models.SortKey {
+	for _, key := range keys {
+		if key.Field == "id" {
+			return keys
+		}
+	}
+
+	return append(keys[:len(keys):len(keys)], models.SortKey{Field: "id"})
+}
+
+// sortSignature identifies the ordering a cursor was issued for, so that a
+// cursor can't be replayed against a listing sorted by other keys.
+func sortSignature(keys []models.SortKey) string {
+	var parts []string
+	for _, key := range keys {
+		if key.Desc {
+			parts = append(parts, "-"+key.Field)
+		} else {
+			parts = append(parts, key.Field)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func EncodeCursor(keys []models.SortKey, song models.SongResponse) (string, error) {
+	cursor := models.SongCursor{
+		Sort: sortSignature(keys),
+		ID:   song.ID,
+	}
+
+	for _, key := range keys {
+		switch key.Field {
+		case "id":
+			continue
+		case "name":
+			cursor.Values = append(cursor.Values, song.Name)
+		case "group":
+			cursor.Values = append(cursor.Values, song.Group)
+		case "releaseDate":
+			releaseDate := "0001-01-01"
+			if !song.ReleaseDate.IsZero() {
+				releaseDate = song.ReleaseDate.Format("2006-01-02")
+			}
+			cursor.Values = append(cursor.Values, releaseDate)
+		default:
+			return "", fmt.Errorf("unknown sort field %q", key.Field)
+		}
+	}
+
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func DecodeCursor(keys []models.SortKey, encoded string) (*models.SongCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, services.ErrInvalidCursor
+	}
+
+	var cursor models.SongCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, services.ErrInvalidCursor
+	}
+
+	if cursor.Sort != sortSignature(keys) || !validCursorValues(keys, cursor) {
+		return nil, services.ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// validCursorValues checks that the cursor carries one value of the right
+// type for every sort key but the id, so that a tampered cursor is refused
+// here rather than by the database.
+func validCursorValues(keys []models.SortKey, cursor models.SongCursor) bool {
+	if cursor.ID <= 0 {
+		return false
+	}
+
+	values := cursor.Values
+	for _, key := range keys {
+		if key.Field == "id" {
+			continue
+		}
+
+		if len(values) == 0 {
+			return false
+		}
+		value := values[0]
+		values = values[1:]
+
+		// Postgres text can't hold NUL bytes.
+		if strings.ContainsRune(value, 0) {
+			return false
+		}
+
+		if key.Field == "releaseDate" {
+			if _, err := time.Parse(time.DateOnly, value); err != nil {
+				return false
+			}
+		}
+	}
+
+	return len(values) == 0
+}
@@ -0,0 +1,133 @@
+package song
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/services"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	byName        = []models.SortKey{{Field: "name"}, {Field: "id"}}
+	byDateDesc    = []models.SortKey{{Field: "releaseDate", Desc: true}, {Field: "group"}, {Field: "id"}}
+	byGroupThenID = []models.SortKey{{Field: "group"}, {Field: "id", Desc: true}}
+)
+
+func song(id int64, group, name string, releaseDate time.Time) models.SongResponse {
+	return models.SongResponse{
+		ID:          id,
+		SongRequest: models.SongRequest{Group: group, Name: name},
+		SongDetail:  models.SongDetail{ReleaseDate: releaseDate},
+	}
+}
+
+func encoded(t *testing.T, cursor string) string {
+	t.Helper()
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+func TestWithTieBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []models.SortKey
+		want []models.SortKey
+	}{
+		{"no keys", nil, []models.SortKey{{Field: "id"}}},
+		{"appended last", []models.SortKey{{Field: "name", Desc: true}}, []models.SortKey{{Field: "name", Desc: true}, {Field: "id"}}},
+		{"id already present", []models.SortKey{{Field: "id", Desc: true}, {Field: "name"}}, []models.SortKey{{Field: "id", Desc: true}, {Field: "name"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withTieBreaker(tt.keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withTieBreaker() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The caller's slice is never written to, even when it has spare capacity.
+	keys := make([]models.SortKey, 1, 2)
+	keys[0] = models.SortKey{Field: "name"}
+	withTieBreaker(keys)
+	if spare := keys[:2][1]; spare != (models.SortKey{}) {
+		t.Errorf("withTieBreaker() wrote %v past the end of the caller's keys", spare)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	releaseDate := time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		keys       []models.SortKey
+		song       models.SongResponse
+		wantSort   string
+		wantValues []string
+	}{
+		{"id only", []models.SortKey{{Field: "id", Desc: true}}, song(7, "Muse", "Uprising", releaseDate), "-id", nil},
+		{"name", byName, song(7, "Muse", "Uprising", releaseDate), "name,id", []string{"Uprising"}},
+		{"mixed directions", byDateDesc, song(7, "Muse", "Uprising", releaseDate), "-releaseDate,group,id", []string{"2006-07-16", "Muse"}},
+		{"missing release date", byDateDesc, song(7, "Muse", "Uprising", time.Time{}), "-releaseDate,group,id", []string{"0001-01-01", "Muse"}},
+		{"song without a group", byGroupThenID, song(7, "", "Uprising", releaseDate), "group,-id", []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := EncodeCursor(tt.keys, tt.song)
+			if err != nil {
+				t.Fatalf("EncodeCursor() error = %v", err)
+			}
+
+			cursor, err := DecodeCursor(tt.keys, token)
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+
+			want := &models.SongCursor{Sort: tt.wantSort, Values: tt.wantValues, ID: tt.song.ID}
+			if !reflect.DeepEqual(cursor, want) {
+				t.Errorf("DecodeCursor() = %+v, want %+v", cursor, want)
+			}
+		})
+	}
+
+	if _, err := EncodeCursor([]models.SortKey{{Field: "link"}}, song(7, "Muse", "Uprising", releaseDate)); err == nil {
+		t.Errorf("EncodeCursor() with an unknown field: error = nil, want an error")
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	byNameToken, err := EncodeCursor(byName, song(7, "Muse", "Uprising", time.Time{}))
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		keys   []models.SortKey
+		cursor string
+	}{
+		{"replayed with other sort keys", byDateDesc, byNameToken},
+		{"replayed with another direction", []models.SortKey{{Field: "name", Desc: true}, {Field: "id"}}, byNameToken},
+		{"not base64", byName, "not base64!"},
+		{"padded base64", byName, base64.URLEncoding.EncodeToString([]byte(`{"s":"name,id","v":["a"],"id":1}`))},
+		{"not JSON", byName, encoded(t, `name,id`)},
+		{"wrong JSON types", byName, encoded(t, `{"s":"name,id","v":"a","id":"1"}`)},
+		{"missing id", byName, encoded(t, `{"s":"name,id","v":["a"]}`)},
+		{"negative id", byName, encoded(t, `{"s":"name,id","v":["a"],"id":-1}`)},
+		{"too few values", byDateDesc, encoded(t, `{"s":"-releaseDate,group,id","v":["2006-07-16"],"id":1}`)},
+		{"too many values", byName, encoded(t, `{"s":"name,id","v":["a","b"],"id":1}`)},
+		{"bad date", byDateDesc, encoded(t, `{"s":"-releaseDate,group,id","v":["16.07.2006","Muse"],"id":1}`)},
+		{"NUL byte", byName, encoded(t, `{"s":"name,id","v":["a\u0000"],"id":1}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.keys, tt.cursor); !errors.Is(err, services.ErrInvalidCursor) {
+				t.Errorf("DecodeCursor() error = %v, want %v", err, services.ErrInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package song
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/client"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+func (s *Service) GetEnrichment(ctx context.Context, id int64) (models.Enrichment, error) {
+	const op = "services.song.GetEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start fetching song enrichment", slog.Int64("songID", id))
+
+	enrichment, err := s.provider.GetEnrichment(ctx, id)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error fetching song enrichment", lg.Err(err))
+		return models.Enrichment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return enrichment, nil
+}
+
+// Reenrich queues the song for enrichment again, whatever its current status.
+// Like the first run it only fills in the details that are still empty: to
+// take a fresh value from the providers, clear the field first.
+func (s *Service) Reenrich(ctx context.Context, id int64) (models.Enrichment, error) {
+	const op = "services.song.Reenrich"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start re-enriching song", slog.Int64("songID", id))
+
+	if err := s.provider.EnqueueEnrichment(ctx, id); err != nil {
+		s.log.ErrorContext(ctx, "error queueing song enrichment", lg.Err(err))
+		return models.Enrichment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.InfoContext(ctx, "song queued for enrichment", slog.Int64("songID", id))
+	return s.GetEnrichment(ctx, id)
+}
+
+// ProcessNextEnrichment claims one due enrichment job and runs it. It reports
+// false when there was nothing to do, so the caller can back off polling.
+func (s *Service) ProcessNextEnrichment(ctx context.Context) (bool, error) {
+	const op = "services.song.ProcessNextEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	jobs, err := s.provider.ClaimEnrichmentJobs(ctx, 1, s.enrichment.JobLease)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(jobs) == 0 {
+		return false, nil
+	}
+
+	if err := s.enrichSong(ctx, jobs[0]); err != nil {
+		return true, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (s *Service) enrichSong(ctx context.Context, job models.EnrichmentJob) error {
+	log := s.log.With(slog.Int64("songID", job.SongID), slog.Int("attempt", job.Attempts))
+	log.DebugContext(ctx, "start song enrichment")
+
+	songResp, _, err := s.getSongAndGroup(ctx, job.SongID, false)
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			log.DebugContext(ctx, "song was deleted before enrichment")
+			return nil
+		}
+		return err
+	}
+
+	songDetail, err := s.metadata.GetSongDetail(ctx, songResp.SongRequest)
+	if err == nil {
+		var field string
+		if field, err = ValidateSongDetails(songDetail); err != nil {
+			err = client.ErrBadPayload.Withf("'%s' %s", field, err)
+		}
+	}
+	if err != nil {
+		return s.failEnrichment(ctx, log, job, err)
+	}
+
+	if err := s.provider.CompleteEnrichment(ctx, job, songDetail); err != nil {
+		if errors.Is(err, storage.ErrEnrichmentLeaseLost) {
+			log.WarnContext(ctx, "enrichment lease expired, dropping the result")
+			return nil
+		}
+		return fmt.Errorf("error completing enrichment: %w", err)
+	}
+
+	log.InfoContext(ctx, "song enriched successfully")
+	return nil
+}
+
+// failEnrichment retries only while the upstream is unavailable; a missing
+// song or a bad payload will not get better on its own.
+func (s *Service) failEnrichment(ctx context.Context, log *slog.Logger, job models.EnrichmentJob, cause error) error {
+	var retryAt *time.Time
+
+	if errors.Is(cause, client.ErrUpstreamUnavailable) && job.Attempts < s.enrichment.MaxAttempts {
+		next := time.Now().Add(s.retryBackoff(job.Attempts))
+		retryAt = &next
+		log.WarnContext(ctx, "song enrichment failed, will retry", lg.Err(cause), slog.Time("retryAt", next))
+	} else {
+		log.ErrorContext(ctx, "song enrichment failed", lg.Err(cause))
+	}
+
+	if err := s.provider.FailEnrichment(ctx, job, cause.Error(), retryAt); err != nil {
+		if errors.Is(err, storage.ErrEnrichmentLeaseLost) {
+			log.WarnContext(ctx, "enrichment lease expired, dropping the failure")
+			return nil
+		}
+		return fmt.Errorf("error recording enrichment failure: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) retryBackoff(attempt int) time.Duration {
+	backoff := s.enrichment.RetryBackoff
+	for i := 1; i < attempt && backoff < s.enrichment.RetryBackoffMax; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, s.enrichment.RetryBackoffMax)
+}
@@ -1,65 +1,150 @@
 package song
 
 import (
-	"database/sql"
+	"context"
 	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/services"
 	"effectivemobiletesttask/internal/storage"
 	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/tracing"
 	"errors"
 	"fmt"
 	"log/slog"
 )
 
-func (s *Service) CreateGroup(groupName string) (int64, error) {
+func (s *Service) CreateGroup(ctx context.Context, groupName string) (int64, error) {
 	const op = "services.song.CreateGroup"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	s.log.Debug("start creating group")
-	id, err := s.provider.CreateGroup(groupName)
+	s.log.DebugContext(ctx, "start creating group")
+	id, err := s.provider.CreateGroup(ctx, groupName)
 	if err != nil {
-		s.log.Error("error during creating group", lg.Err(err))
+		s.log.ErrorContext(ctx, "error during creating group", lg.Err(err))
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	s.log.Debug("created group", slog.Any("id", id))
+	s.log.DebugContext(ctx, "created group", slog.Any("id", id))
 
 	return id, nil
 }
 
-func (s *Service) GetGroupByID(id int64) (models.Group, error) {
+// GetGroupByID returns the group. Groups in the trash are only returned with
+// includeDeleted.
+func (s *Service) GetGroupByID(ctx context.Context, id int64, includeDeleted bool) (models.GroupResponse, error) {
 	const op = "services.song.GetGroupByID"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	s.log.Debug("start fetching group")
-	group, err := s.provider.GetGroupByID(id)
+	s.log.DebugContext(ctx, "start fetching group")
+	group, err := s.provider.GetGroupByID(ctx, id, includeDeleted)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.log.Error("group was not found")
-
-			return models.Group{}, storage.ErrGroupNotFound
+		if !errors.Is(err, storage.ErrGroupNotFound) {
+			s.log.ErrorContext(ctx, "error during fetching group", lg.Err(err))
 		}
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		s.log.Error("error during fetching group", lg.Err(err))
-		return models.Group{}, fmt.Errorf("%s: %w", op, err)
+	count, err := s.provider.CountGroupSongs(ctx, id)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error during counting group songs", lg.Err(err))
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
-	s.log.Debug("fetched group", slog.Any("group", group))
+	s.log.DebugContext(ctx, "fetched group", slog.Any("group", group), slog.Int64("songsCount", count))
 
-	return group, nil
+	return models.GroupResponse{Group: group, SongsCount: count}, nil
 }
 
-func (s *Service) GetGroupByName(groupName string) (models.Group, error) {
+func (s *Service) GetGroupByName(ctx context.Context, groupName string) (models.Group, error) {
 	const op = "services.song.GetGroupByName"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	s.log.Debug("start fetching group")
-	group, err := s.provider.GetGroupByName(groupName)
+	s.log.DebugContext(ctx, "start fetching group")
+	group, err := s.provider.GetGroupByName(ctx, groupName)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.log.Error("group was not found")
-
-			return models.Group{}, storage.ErrGroupNotFound
+		if !errors.Is(err, storage.ErrGroupNotFound) {
+			s.log.ErrorContext(ctx, "error during fetching group", lg.Err(err))
 		}
-
-		s.log.Error("error during fetching group", lg.Err(err))
 		return models.Group{}, fmt.Errorf("%s: %w", op, err)
 	}
-	s.log.Debug("fetched group", slog.Any("group", group))
+	s.log.DebugContext(ctx, "fetched group", slog.Any("group", group))
 
 	return group, nil
 }
+
+func (s *Service) GetAllGroups(ctx context.Context, filter models.GroupFilter, offset int, limit int) ([]models.GroupResponse, error) {
+	const op = "services.song.GetAllGroups"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start fetching groups with filters", slog.Any("filter", filter), slog.Int("offset", offset), slog.Int("limit", limit))
+	groups, err := s.provider.GetAllGroups(ctx, filter, offset, limit)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error during fetching groups", lg.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.DebugContext(ctx, "fetched groups successfully", slog.Int("totalGroups", len(groups)))
+
+	return groups, nil
+}
+
+func (s *Service) RenameGroup(ctx context.Context, id int64, groupName string) (models.GroupResponse, error) {
+	const op = "services.song.RenameGroup"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start renaming group", slog.Int64("groupID", id), slog.String("group", groupName))
+
+	existing, err := s.GetGroupByName(ctx, groupName)
+	if err != nil && !errors.Is(err, storage.ErrGroupNotFound) {
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if existing.ID != 0 && existing.ID != id {
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, storage.ErrGroupExists)
+	}
+
+	if _, err := s.provider.UpdateGroup(ctx, id, groupName, songChange(ctx, models.RevisionUpdate)); err != nil {
+		s.log.ErrorContext(ctx, "error during renaming group", lg.Err(err))
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.DebugContext(ctx, "renamed group successfully", slog.Int64("groupID", id))
+
+	return s.GetGroupByID(ctx, id, false)
+}
+
+func (s *Service) MergeGroups(ctx context.Context, sourceID int64, targetID int64) (models.GroupResponse, error) {
+	const op = "services.song.MergeGroups"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start merging groups", slog.Int64("sourceID", sourceID), slog.Int64("targetID", targetID))
+
+	if sourceID == targetID {
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, services.ErrMergeIntoItself)
+	}
+
+	if err := s.provider.MergeGroups(ctx, sourceID, targetID, songChange(ctx, models.RevisionUpdate)); err != nil {
+		s.log.ErrorContext(ctx, "error during merging groups", lg.Err(err))
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.InfoContext(ctx, "merged groups successfully", slog.Int64("sourceID", sourceID), slog.Int64("targetID", targetID))
+
+	return s.GetGroupByID(ctx, targetID, false)
+}
+
+// DeleteGroup moves the group to the trash, with cascade together with its
+// songs.
+func (s *Service) DeleteGroup(ctx context.Context, id int64, cascade bool) error {
+	const op = "services.song.DeleteGroup"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start deleting group", slog.Int64("groupID", id), slog.Bool("cascade", cascade))
+	if err := s.provider.DeleteGroup(ctx, id, cascade, songChange(ctx, models.RevisionDelete)); err != nil {
+		s.log.ErrorContext(ctx, "error deleting group", lg.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.DebugContext(ctx, "deleted group successfully", slog.Int64("groupID", id))
+
+	return nil
+}
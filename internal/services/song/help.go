@@ -1,22 +1,36 @@
 package song
 
 import (
+	"context"
 	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/services"
 	"effectivemobiletesttask/internal/storage"
 	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/lyrics"
+	"effectivemobiletesttask/internal/utils/tracing"
 	"errors"
 	"fmt"
 	"log/slog"
 )
 
-func (s *Service) createOrGetGroup(groupName string) (int64, error) {
-	group, err := s.GetGroupByName(groupName)
+func (s *Service) createOrGetGroup(ctx context.Context, groupName string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "services.song.createOrGetGroup")
+	defer span.End()
+
+	group, err := s.GetGroupByName(ctx, groupName)
 	if err != nil && !errors.Is(err, storage.ErrGroupNotFound) {
 		return 0, fmt.Errorf("error fetching group: %w", err)
 	}
 
 	if group.ID == 0 {
-		groupID, err := s.CreateGroup(groupName)
+		groupID, err := s.CreateGroup(ctx, groupName)
+		if errors.Is(err, storage.ErrGroupExists) {
+			group, err = s.GetGroupByName(ctx, groupName)
+			if err != nil {
+				return 0, fmt.Errorf("error fetching group: %w", err)
+			}
+			return group.ID, nil
+		}
 
 		if err != nil {
 			return 0, fmt.Errorf("error creating group: %w", err)
@@ -26,13 +40,13 @@ func (s *Service) createOrGetGroup(groupName string) (int64, error) {
 	return group.ID, nil
 }
 
-func (s *Service) getSongAndGroup(id int64) (models.SongResponse, models.Group, error) {
-	song, err := s.provider.GetSongByID(id)
+func (s *Service) getSongAndGroup(ctx context.Context, id int64, includeDeleted bool) (models.SongResponse, models.Group, error) {
+	song, err := s.provider.GetSongByID(ctx, id, includeDeleted)
 	if err != nil {
 		return models.SongResponse{}, models.Group{}, fmt.Errorf("error fetching song: %w", err)
 	}
 
-	group, err := s.provider.GetGroupByID(song.GroupID)
+	group, err := s.provider.GetGroupByID(ctx, song.GroupID, true)
 	if err != nil {
 		return models.SongResponse{}, models.Group{}, fmt.Errorf("error fetching group: %w", err)
 	}
@@ -40,7 +54,7 @@ func (s *Service) getSongAndGroup(id int64) (models.SongResponse, models.Group,
 	return SongToSongResp(song, group.Name), group, nil
 }
 
-func (s *Service) logSongsWithoutText(songs []models.SongStorage) []models.SongStorage {
+func (s *Service) logSongsWithoutText(ctx context.Context, songs []models.SongStorage) []models.SongStorage {
 	var songsWithoutText []models.SongStorage
 	for _, song := range songs {
 		songCopy := song
@@ -48,19 +62,42 @@ func (s *Service) logSongsWithoutText(songs []models.SongStorage) []models.SongS
 		songCopy.Link = ""
 		songsWithoutText = append(songsWithoutText, songCopy)
 	}
-	s.log.Debug("fetched songs: ", slog.Any("songs", songsWithoutText))
+	s.log.DebugContext(ctx, "fetched songs: ", slog.Any("songs", songsWithoutText))
 	return songsWithoutText
 }
 
-func (s *Service) fetchGroups(songs []models.SongStorage) ([]models.Group, error) {
+func (s *Service) fetchGroups(ctx context.Context, songs []models.SongStorage) ([]models.Group, error) {
 	var groups []models.Group
 	for _, song := range songs {
-		group, err := s.provider.GetGroupByID(song.GroupID)
+		group, err := s.provider.GetGroupByID(ctx, song.GroupID, true)
 		if err != nil {
-			s.log.Error("error during the fetching group", lg.Err(err))
+			s.log.ErrorContext(ctx, "error during the fetching group", lg.Err(err))
 			return nil, err
 		}
 		groups = append(groups, group)
 	}
 	return groups, nil
 }
+
+func paginateVerses(song models.SongResponse, verses models.VerseRange) (models.SongText, error) {
+	allVerses := lyrics.SplitVerses(song.Text)
+
+	if verses.Offset < 0 || (verses.Offset > 0 && verses.Offset >= len(allVerses)) {
+		return models.SongText{}, services.ErrVerseOutOfRange
+	}
+
+	end := min(verses.Offset+verses.Limit, len(allVerses))
+
+	songText := models.SongText{
+		SongID:      song.ID,
+		Verses:      []models.Verse{},
+		TotalVerses: len(allVerses),
+		HasMore:     end < len(allVerses),
+	}
+
+	for i := verses.Offset; i < end; i++ {
+		songText.Verses = append(songText.Verses, models.Verse{Index: i, Lines: allVerses[i]})
+	}
+
+	return songText, nil
+}
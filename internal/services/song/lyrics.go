@@ -0,0 +1,88 @@
+package song
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/lyrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"fmt"
+	"log/slog"
+)
+
+func (s *Service) GetLyrics(ctx context.Context, id int64) (models.Lyrics, error) {
+	const op = "services.song.GetLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start fetching song lyrics", slog.Int64("songID", id))
+
+	songLyrics, err := s.provider.GetLyrics(ctx, id)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error fetching song lyrics", lg.Err(err))
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.DebugContext(ctx, "fetched song lyrics", slog.Int64("songID", id), slog.Int("sections", len(songLyrics.Sections)))
+	return songLyrics, nil
+}
+
+// UpdateLyrics replaces the structured lyrics of the song. The plain text of
+// the song is regenerated from the sections.
+func (s *Service) UpdateLyrics(ctx context.Context, id int64, sections []models.LyricsSection) (models.Lyrics, error) {
+	const op = "services.song.UpdateLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start updating song lyrics", slog.Int64("songID", id), slog.Int("sections", len(sections)))
+
+	change := songChange(ctx, models.RevisionUpdate)
+	if err := s.provider.SaveLyrics(ctx, id, sections, lyrics.ToText(sections), &change); err != nil {
+		s.log.ErrorContext(ctx, "error updating song lyrics", lg.Err(err))
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.InfoContext(ctx, "updated song lyrics", slog.Int64("songID", id))
+	return s.GetLyrics(ctx, id)
+}
+
+// ExportLyrics renders the song lyrics in a synchronized lyrics format
+// (lrc or srt).
+func (s *Service) ExportLyrics(ctx context.Context, id int64, format string) (string, error) {
+	const op = "services.song.ExportLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start exporting song lyrics", slog.Int64("songID", id), slog.String("format", format))
+
+	songLyrics, err := s.GetLyrics(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := lyrics.Format(format, songLyrics.Sections)
+	if err != nil {
+		s.log.DebugContext(ctx, "song lyrics can't be exported", slog.Int64("songID", id), lg.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+// ImportLyrics replaces the song lyrics with synchronized lyrics uploaded in
+// lrc or srt format.
+func (s *Service) ImportLyrics(ctx context.Context, id int64, format string, data string) (models.Lyrics, error) {
+	const op = "services.song.ImportLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start importing song lyrics", slog.Int64("songID", id), slog.String("format", format))
+
+	sections, err := lyrics.Parse(format, data)
+	if err != nil {
+		s.log.DebugContext(ctx, "uploaded lyrics are invalid", slog.Int64("songID", id), lg.Err(err))
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.UpdateLyrics(ctx, id, sections)
+}
@@ -0,0 +1,160 @@
+package song
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/actor"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/diff"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// songChange attributes a write to the actor of ctx.
+func songChange(ctx context.Context, action string) models.SongChange {
+	return models.SongChange{Action: action, Actor: actor.From(ctx)}
+}
+
+// ListSongRevisions returns a page of the song history, newest first. The
+// history of a deleted song is still available.
+func (s *Service) ListSongRevisions(ctx context.Context, id int64, offset int, limit int) ([]models.SongRevision, error) {
+	const op = "services.song.ListSongRevisions"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start fetching song revisions", slog.Int64("songID", id), slog.Int("offset", offset))
+
+	revisions, err := s.provider.ListSongRevisions(ctx, id, offset, limit)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error fetching song revisions", lg.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Songs created before revisions were recorded have no history yet.
+	if len(revisions) == 0 && offset == 0 {
+		if _, err := s.provider.GetSongByID(ctx, id, true); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return revisions, nil
+}
+
+func (s *Service) GetSongRevision(ctx context.Context, id int64, revision int64) (models.SongRevision, error) {
+	const op = "services.song.GetSongRevision"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start fetching song revision", slog.Int64("songID", id), slog.Int64("revision", revision))
+
+	songRevision, err := s.provider.GetSongRevision(ctx, id, revision)
+	if err != nil {
+		return models.SongRevision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songRevision, nil
+}
+
+// DiffSongRevisions compares two revisions of the song. Lyrics are compared
+// line by line, other fields as a whole.
+func (s *Service) DiffSongRevisions(ctx context.Context, id int64, from int64, to int64) (models.SongRevisionDiff, error) {
+	const op = "services.song.DiffSongRevisions"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start diffing song revisions", slog.Int64("songID", id), slog.Int64("from", from), slog.Int64("to", to))
+
+	fromRevision, err := s.provider.GetSongRevision(ctx, id, from)
+	if err != nil {
+		return models.SongRevisionDiff{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	toRevision, err := s.provider.GetSongRevision(ctx, id, to)
+	if err != nil {
+		return models.SongRevisionDiff{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a, b := fromRevision.Snapshot, toRevision.Snapshot
+	fields := []models.FieldChange{}
+
+	for _, field := range []models.FieldChange{
+		{Field: "song", From: a.Name, To: b.Name},
+		{Field: "group", From: a.Group, To: b.Group},
+		{Field: "releaseDate", From: a.ReleaseDate, To: b.ReleaseDate},
+		{Field: "link", From: a.Link, To: b.Link},
+	} {
+		if field.From != field.To {
+			fields = append(fields, field)
+		}
+	}
+
+	return models.SongRevisionDiff{
+		SongID: id,
+		From:   from,
+		To:     to,
+		Fields: fields,
+		Lyrics: diff.Lines(a.Text, b.Text),
+	}, nil
+}
+
+// RestoreSongRevision makes the snapshot of the revision the current state
+// of the song, bringing a deleted song back under its id. The restore is
+// recorded as a new revision. A non-zero version makes it conditional on
+// the song still having that version.
+func (s *Service) RestoreSongRevision(ctx context.Context, id int64, revision int64, version int64) (models.SongResponse, error) {
+	const op = "services.song.RestoreSongRevision"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start restoring song revision", slog.Int64("songID", id), slog.Int64("revision", revision))
+
+	songRevision, err := s.provider.GetSongRevision(ctx, id, revision)
+	if err != nil {
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+	snapshot := songRevision.Snapshot
+
+	current, err := s.provider.GetSongByID(ctx, id, true)
+	if err != nil && !errors.Is(err, storage.ErrSongNotFound) {
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+	purged := err != nil
+
+	if !purged && version != 0 && version != current.Version {
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, storage.ErrSongModified)
+	}
+
+	groupID, err := s.createOrGetGroup(ctx, snapshot.Group)
+	if err != nil {
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	song := models.SongStorage{
+		GroupID: groupID,
+		Name:    snapshot.Name,
+		Version: version,
+	}
+	song.Text = snapshot.Text
+	song.Link = snapshot.Link
+
+	if snapshot.ReleaseDate != "" {
+		song.ReleaseDate, err = time.Parse(time.DateOnly, snapshot.ReleaseDate)
+		if err != nil {
+			return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	change := songChange(ctx, models.RevisionRestore)
+	change.RestoredFrom = revision
+
+	if _, err := s.provider.RestoreSong(ctx, id, song, change); err != nil {
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.InfoContext(ctx, "restored song revision", slog.Int64("songID", id), slog.Int64("revision", revision), slog.Bool("purged", purged))
+	return s.GetSongByID(ctx, id, false)
+}
@@ -0,0 +1,112 @@
+package song
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/config"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+// revisions serves the song history from memory. Every other Provider method
+// panics.
+type revisions struct {
+	Provider
+	snapshots map[int64]models.SongDocument
+}
+
+func (p revisions) GetSongRevision(_ context.Context, songID int64, revision int64) (models.SongRevision, error) {
+	snapshot, ok := p.snapshots[revision]
+	if !ok {
+		return models.SongRevision{}, storage.ErrRevisionNotFound
+	}
+
+	return models.SongRevision{SongID: songID, Revision: revision, Snapshot: snapshot}, nil
+}
+
+func TestDiffSongRevisions(t *testing.T) {
+	original := models.SongDocument{
+		Name:        "Supermassive Black Hole",
+		Group:       "Muse",
+		ReleaseDate: "2006-07-16",
+		Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+
+	renamed := original
+	renamed.Group = "MUSE"
+	renamed.Link = ""
+
+	relyricked := original
+	relyricked.Text = "Ooh baby, don't you know I suffer?\nYou caught me under false pretenses"
+
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)), revisions{snapshots: map[int64]models.SongDocument{
+		1: original,
+		2: renamed,
+		3: relyricked,
+	}}, nil, config.Enrichment{}, config.Trash{})
+
+	tests := []struct {
+		name       string
+		from, to   int64
+		wantFields []models.FieldChange
+		wantLyrics []models.LineChange
+	}{
+		{
+			name:       "same revision",
+			from:       1,
+			to:         1,
+			wantFields: []models.FieldChange{},
+			wantLyrics: []models.LineChange{
+				{Op: models.LineEqual, Text: "Ooh baby, don't you know I suffer?", FromLine: 1, ToLine: 1},
+				{Op: models.LineEqual, Text: "Ooh baby, can you hear me moan?", FromLine: 2, ToLine: 2},
+			},
+		},
+		{
+			name: "fields",
+			from: 1,
+			to:   2,
+			wantFields: []models.FieldChange{
+				{Field: "group", From: "Muse", To: "MUSE"},
+				{Field: "link", From: original.Link, To: ""},
+			},
+			wantLyrics: []models.LineChange{
+				{Op: models.LineEqual, Text: "Ooh baby, don't you know I suffer?", FromLine: 1, ToLine: 1},
+				{Op: models.LineEqual, Text: "Ooh baby, can you hear me moan?", FromLine: 2, ToLine: 2},
+			},
+		},
+		{
+			name:       "lyrics",
+			from:       1,
+			to:         3,
+			wantFields: []models.FieldChange{},
+			wantLyrics: []models.LineChange{
+				{Op: models.LineEqual, Text: "Ooh baby, don't you know I suffer?", FromLine: 1, ToLine: 1},
+				{Op: models.LineDelete, Text: "Ooh baby, can you hear me moan?", FromLine: 2},
+				{Op: models.LineInsert, Text: "You caught me under false pretenses", ToLine: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.DiffSongRevisions(context.Background(), 7, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("DiffSongRevisions() error = %v", err)
+			}
+
+			want := models.SongRevisionDiff{SongID: 7, From: tt.from, To: tt.to, Fields: tt.wantFields, Lyrics: tt.wantLyrics}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DiffSongRevisions() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if _, err := s.DiffSongRevisions(context.Background(), 7, 1, 4); !errors.Is(err, storage.ErrRevisionNotFound) {
+		t.Errorf("DiffSongRevisions() of a missing revision: error = %v, want %v", err, storage.ErrRevisionNotFound)
+	}
+}
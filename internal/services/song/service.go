@@ -1,38 +1,88 @@
 package song
 
 import (
+	"context"
 	"effectivemobiletesttask/internal/config"
 	"effectivemobiletesttask/internal/domain/models"
 	"effectivemobiletesttask/internal/services"
 	"log/slog"
+	"time"
 )
 
 type Provider interface {
 	// Song
-	CreateSong(song models.SongStorage) (int64, error)
-	GetSongByID(id int64) (models.SongStorage, error)
-	GetSongByName(songName string) (models.SongStorage, error)
-	UpdateSong(id int64, song models.SongStorage) (models.SongStorage, error)
-	DeleteSong(id int64) error
-	GetAllSongs(filter models.SongFilter, groupID int64, offset int, limit int) ([]models.SongStorage, error)
+	CreateSong(ctx context.Context, song models.SongStorage, change models.SongChange) (int64, error)
+	GetSongByID(ctx context.Context, id int64, includeDeleted bool) (models.SongStorage, error)
+	GetSongByName(ctx context.Context, songName string) (models.SongStorage, error)
+	UpdateSong(ctx context.Context, id int64, song models.SongStorage, change models.SongChange) (models.SongStorage, error)
+	DeleteSong(ctx context.Context, id int64, version int64, change models.SongChange) error
+	GetAllSongs(
+		ctx context.Context,
+		filter models.SongFilter,
+		after *models.SongCursor,
+		limit int,
+	) ([]models.SongStorage, error)
+	CountSongs(ctx context.Context, filter models.SongFilter) (int64, error)
+	SearchSongs(ctx context.Context, search models.SongSearch) ([]models.SongSearchHit, error)
+	GetLyrics(ctx context.Context, songID int64) (models.Lyrics, error)
+	SaveLyrics(ctx context.Context, songID int64, sections []models.LyricsSection, text string, change *models.SongChange) error
+
+	// Revisions
+	ListSongRevisions(ctx context.Context, songID int64, offset int, limit int) ([]models.SongRevision, error)
+	GetSongRevision(ctx context.Context, songID int64, revision int64) (models.SongRevision, error)
+	RestoreSong(ctx context.Context, id int64, song models.SongStorage, change models.SongChange) (models.SongStorage, error)
+
+	// Enrichment
+	EnqueueEnrichment(ctx context.Context, songID int64) error
+	ClaimEnrichmentJobs(ctx context.Context, limit int, lease time.Duration) ([]models.EnrichmentJob, error)
+	CompleteEnrichment(ctx context.Context, job models.EnrichmentJob, detail models.SongDetail) error
+	FailEnrichment(ctx context.Context, job models.EnrichmentJob, errMsg string, retryAt *time.Time) error
+	GetEnrichment(ctx context.Context, songID int64) (models.Enrichment, error)
 
 	// Group
-	CreateGroup(groupName string) (int64, error)
-	GetGroupByID(id int64) (models.Group, error)
-	GetGroupByName(groupName string) (models.Group, error)
+	CreateGroup(ctx context.Context, groupName string) (int64, error)
+	GetGroupByID(ctx context.Context, id int64, includeDeleted bool) (models.Group, error)
+	GetGroupByName(ctx context.Context, groupName string) (models.Group, error)
+	GetAllGroups(ctx context.Context, filter models.GroupFilter, offset int, limit int) ([]models.GroupResponse, error)
+	CountGroupSongs(ctx context.Context, id int64) (int64, error)
+	UpdateGroup(ctx context.Context, id int64, groupName string, change models.SongChange) (models.Group, error)
+	MergeGroups(ctx context.Context, sourceID int64, targetID int64, change models.SongChange) error
+	DeleteGroup(ctx context.Context, id int64, cascade bool, change models.SongChange) error
+
+	// Trash
+	UndeleteSong(ctx context.Context, id int64, change models.SongChange) error
+	UndeleteGroup(ctx context.Context, id int64, change models.SongChange) error
+	ListTrash(ctx context.Context, offset int, limit int) ([]models.TrashItem, error)
+	PurgeTrash(ctx context.Context, before time.Time) (models.PurgeResult, error)
+}
+
+// MetadataProvider supplies the details used to enrich songs. The app passes
+// a metadata.Chain built from the configured providers.
+type MetadataProvider interface {
+	GetSongDetail(ctx context.Context, songReq models.SongRequest) (models.SongDetail, error)
 }
 
 type Service struct {
-	log      *slog.Logger
-	provider Provider
-	api      config.APIClient
+	log        *slog.Logger
+	provider   Provider
+	metadata   MetadataProvider
+	enrichment config.Enrichment
+	trash      config.Trash
 }
 
-func New(log *slog.Logger, provider Provider, api config.APIClient) *Service {
+func New(
+	log *slog.Logger,
+	provider Provider,
+	metadata MetadataProvider,
+	enrichment config.Enrichment,
+	trash config.Trash,
+) *Service {
 	return &Service{
-		log:      log,
-		provider: provider,
-		api:      api,
+		log:        log,
+		provider:   provider,
+		metadata:   metadata,
+		enrichment: enrichment,
+		trash:      trash,
 	}
 }
 
@@ -45,6 +95,9 @@ func SongToSongResp(song models.SongStorage, groupName string) models.SongRespon
 	songResp.ReleaseDate = song.ReleaseDate
 	songResp.Text = song.Text
 	songResp.Link = song.Link
+	songResp.EnrichmentStatus = song.EnrichmentStatus
+	songResp.Version = song.Version
+	songResp.DeletedAt = song.DeletedAt
 
 	return songResp
 }
@@ -58,6 +111,7 @@ func SongRespToSongStorage(songResp models.SongResponse, groupID int64) models.S
 	song.ReleaseDate = songResp.ReleaseDate
 	song.Text = songResp.Text
 	song.Link = songResp.Link
+	song.Version = songResp.Version
 
 	return song
 }
@@ -74,20 +128,17 @@ func SongReqAndDetsToSong(
 	song.ReleaseDate = songDetail.ReleaseDate
 	song.Text = songDetail.Text
 	song.Link = songDetail.Link
+	song.EnrichmentStatus = models.EnrichmentPending
 
 	return song
 }
 
+// ValidateSongDetails checks the details returned by the info API. Text and
+// link are optional: a song without them is still worth enriching.
 func ValidateSongDetails(songDetail models.SongDetail) (string, error) {
 	if songDetail.ReleaseDate.IsZero() {
 		return "releaseDate", services.ErrFieldIsRequired
 	}
-	if songDetail.Text == "" {
-		return "text", services.ErrFieldIsRequired
-	}
-	if songDetail.Link == "" {
-		return "link", services.ErrFieldIsRequired
-	}
 
 	return "", nil
 }
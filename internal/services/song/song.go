@@ -1,195 +1,307 @@
 package song
 
 import (
-	"effectivemobiletesttask/internal/client/song"
+	"context"
 	"effectivemobiletesttask/internal/domain/models"
 	"effectivemobiletesttask/internal/storage"
 	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/tracing"
 	"errors"
 	"fmt"
 	"log/slog"
-	"strings"
 )
 
-func (s *Service) CreateSong(songReq models.SongRequest) (int64, error) {
+func (s *Service) CreateSong(ctx context.Context, songReq models.SongRequest) (int64, error) {
 	const op = "services.song.CreateSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	s.log.With(slog.String("operation", op))
-	s.log.Debug("start song creation", slog.String("songName", songReq.Name), slog.String("group", songReq.Group))
+	s.log.DebugContext(ctx, "start song creation", slog.String("songName", songReq.Name), slog.String("group", songReq.Group))
 
-	groupID, err := s.createOrGetGroup(songReq.Group)
+	groupID, err := s.createOrGetGroup(ctx, songReq.Group)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	s.log.Debug("group retrieved or created", slog.Int64("groupID", groupID))
+	s.log.DebugContext(ctx, "group retrieved or created", slog.Int64("groupID", groupID))
 
-	songDetail, err := song.NewClient(s.log, s.api).GetSongDetail(songReq)
+	// Details are filled in later by the enrichment workers.
+	song := SongReqAndDetsToSong(songReq, models.SongDetail{}, groupID)
+	id, err := s.provider.CreateSong(ctx, song, songChange(ctx, models.RevisionCreate))
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	s.log.Debug("fetched song details", slog.String("songReleaseDate", songDetail.ReleaseDate.Format("2006-01-02")))
 
-	field, err := ValidateSongDetails(songDetail)
-	if err != nil {
-		return 0, fmt.Errorf("%s: '%s' %w", op, field, err)
-	}
-
-	song := SongReqAndDetsToSong(songReq, songDetail, groupID)
-	id, err := s.provider.CreateSong(song)
-	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
-	}
-
-	s.log.Info("song created successfully", slog.Int64("songID", id), slog.String("songReleaseDate", songDetail.ReleaseDate.Format("2006-01-02")))
+	s.log.InfoContext(ctx, "song created successfully, enrichment queued", slog.Int64("songID", id))
 	return id, nil
 }
 
-func (s *Service) GetSongByID(id int64) (models.SongResponse, error) {
+// GetSongByID returns the song. Songs in the trash are only returned with
+// includeDeleted.
+func (s *Service) GetSongByID(ctx context.Context, id int64, includeDeleted bool) (models.SongResponse, error) {
 	const op = "services.song.GetSongByID"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	s.log.With(slog.String("operation", op))
-	s.log.Debug("start fetching song by ID", slog.Int64("songID", id))
+	s.log.DebugContext(ctx, "start fetching song by ID", slog.Int64("songID", id), slog.Bool("includeDeleted", includeDeleted))
 
-	songResp, _, err := s.getSongAndGroup(id)
+	songResp, _, err := s.getSongAndGroup(ctx, id, includeDeleted)
 	if err != nil {
-		s.log.Error("error fetching song", lg.Err(err))
+		s.log.ErrorContext(ctx, "error fetching song", lg.Err(err))
 		return models.SongResponse{}, err
 	}
 
-	s.log.Debug("fetched song successfully", slog.Int64("songID", id), slog.String("songName", songResp.Name))
+	s.log.DebugContext(ctx, "fetched song successfully", slog.Int64("songID", id), slog.String("songName", songResp.Name))
 	return songResp, nil
 }
 
-func (s *Service) GetSongByName(songName string) (models.SongResponse, error) {
+func (s *Service) GetSongByName(ctx context.Context, songName string) (models.SongResponse, error) {
 	const op = "services.song.GetSongByName"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	s.log.With(slog.String("operation", op))
-	s.log.Debug("start fetching song by name", slog.String("songName", songName))
+	s.log.DebugContext(ctx, "start fetching song by name", slog.String("songName", songName))
 
-	song, err := s.provider.GetSongByName(songName)
+	song, err := s.provider.GetSongByName(ctx, songName)
 	if err != nil {
-		s.log.Error("error fetching song by name", lg.Err(err))
+		s.log.ErrorContext(ctx, "error fetching song by name", lg.Err(err))
 		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.GetSongByID(song.ID)
+	return s.GetSongByID(ctx, song.ID, false)
 }
 
-func (s *Service) GetSongTextByID(id int64, verse int) (string, error) {
+func (s *Service) GetSongTextByID(ctx context.Context, id int64, verses models.VerseRange) (models.SongText, error) {
 	const op = "services.song.GetSongTextByID"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	s.log.With(slog.String("operation", op))
-	s.log.Debug("start fetching song text by ID", slog.Int64("songID", id), slog.Int("verse", verse))
+	s.log.DebugContext(ctx, "start fetching song text by ID", slog.Int64("songID", id), slog.Int("verseOffset", verses.Offset), slog.Int("verseLimit", verses.Limit))
 
-	songResp, _, err := s.getSongAndGroup(id)
+	songResp, _, err := s.getSongAndGroup(ctx, id, false)
 	if err != nil {
-		return "", err
+		return models.SongText{}, err
 	}
 
-	songVerses := strings.Split(songResp.Text, "\n\n")
-	if verse > len(songVerses)-1 {
-		verse = len(songVerses) - 1
-	}
-	if verse < 0 {
-		verse = 0
+	songText, err := paginateVerses(songResp, verses)
+	if err != nil {
+		return models.SongText{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	s.log.Debug("fetched song text", slog.String("verseText", songVerses[verse]))
-	return songVerses[verse], nil
+	s.log.DebugContext(ctx, "fetched song text", slog.Int("verses", len(songText.Verses)), slog.Int("totalVerses", songText.TotalVerses))
+	return songText, nil
 }
 
-func (s *Service) GetSongTextByName(songName string, verse int) (string, error) {
+func (s *Service) GetSongTextByName(ctx context.Context, songName string, verses models.VerseRange) (models.SongText, error) {
 	const op = "services.song.GetSongTextByName"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	s.log.With(slog.String("operation", op))
-	s.log.Debug("start fetching song text by name", slog.String("songName", songName), slog.Int("verse", verse))
+	s.log.DebugContext(ctx, "start fetching song text by name", slog.String("songName", songName), slog.Int("verseOffset", verses.Offset), slog.Int("verseLimit", verses.Limit))
 
-	songResp, err := s.GetSongByName(songName)
+	songResp, err := s.GetSongByName(ctx, songName)
 	if err != nil {
-		return "", err
+		return models.SongText{}, err
 	}
 
-	songVerses := strings.Split(songResp.Text, "\n\n")
-	if verse > len(songVerses)-1 {
-		verse = len(songVerses) - 1
-	}
-	if verse < 0 {
-		verse = 0
+	songText, err := paginateVerses(songResp, verses)
+	if err != nil {
+		return models.SongText{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	s.log.Debug("fetched song text", slog.String("verseText", songVerses[verse]))
-	return songVerses[verse], nil
+	s.log.DebugContext(ctx, "fetched song text", slog.Int("verses", len(songText.Verses)), slog.Int("totalVerses", songText.TotalVerses))
+	return songText, nil
 }
 
-func (s *Service) UpdateSong(id int64, newSong models.SongResponse) (models.SongResponse, error) {
+// patchAttempts limits how often a patch is reapplied when the song changes
+// between reading and writing it.
+const patchAttempts = 3
+
+// UpdateSong replaces the song. A non-zero newSong.Version makes the update
+// fail with storage.ErrSongModified unless the song still has that version.
+// Both are checked before the group is created, so a failed update leaves no
+// new group behind.
+func (s *Service) UpdateSong(ctx context.Context, id int64, newSong models.SongResponse) (models.SongResponse, error) {
 	const op = "services.song.UpdateSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	s.log.With(slog.String("operation", op))
 
-	s.log.Debug("start updating song", slog.Int64("songID", id), slog.String("songName", newSong.Name))
+	s.log.DebugContext(ctx, "start updating song", slog.Int64("songID", id), slog.String("songName", newSong.Name))
 
-	groupID, err := s.createOrGetGroup(newSong.Group)
+	current, err := s.provider.GetSongByID(ctx, id, false)
 	if err != nil {
 		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
-	s.log.Debug("group retrieved or created for update", slog.Int64("groupID", groupID))
 
-	_, err = s.provider.UpdateSong(id, SongRespToSongStorage(newSong, groupID))
+	if newSong.Version != 0 && newSong.Version != current.Version {
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, storage.ErrSongModified)
+	}
+
+	groupID, err := s.createOrGetGroup(ctx, newSong.Group)
 	if err != nil {
 		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
+	s.log.DebugContext(ctx, "group retrieved or created for update", slog.Int64("groupID", groupID))
 
-	s.log.Debug("updated song successfully", slog.Int64("songID", id), slog.String("songName", newSong.Name))
-	return newSong, nil
+	_, err = s.provider.UpdateSong(ctx, id, SongRespToSongStorage(newSong, groupID), songChange(ctx, models.RevisionUpdate))
+	if err != nil {
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.DebugContext(ctx, "updated song successfully", slog.Int64("songID", id), slog.String("songName", newSong.Name))
+
+	// Return what was stored rather than the request: it carries the
+	// enrichment status and the lyrics synced above.
+	return s.GetSongByID(ctx, id, false)
 }
 
-func (s *Service) DeleteSong(id int64) error {
+// PatchSong reads the song, applies patch to it and stores the result on
+// condition that the song was not modified in between. Otherwise the patch
+// is applied again to the new version.
+func (s *Service) PatchSong(
+	ctx context.Context,
+	id int64,
+	patch func(current models.SongResponse) (models.SongResponse, error),
+) (models.SongResponse, error) {
+	const op = "services.song.PatchSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.With(slog.String("operation", op))
+
+	for attempt := 1; ; attempt++ {
+		current, err := s.GetSongByID(ctx, id, false)
+		if err != nil {
+			return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		newSong, err := patch(current)
+		if err != nil {
+			return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+		}
+		newSong.Version = current.Version
+
+		song, err := s.UpdateSong(ctx, id, newSong)
+		if errors.Is(err, storage.ErrSongModified) && attempt < patchAttempts {
+			s.log.DebugContext(ctx, "song modified concurrently, reapplying patch", slog.Int64("songID", id), slog.Int("attempt", attempt))
+			continue
+		}
+		if err != nil {
+			return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return song, nil
+	}
+}
+
+// DeleteSong moves the song to the trash. A non-zero version makes the delete
+// conditional on the song still having it.
+func (s *Service) DeleteSong(ctx context.Context, id int64, version int64) error {
 	const op = "services.song.DeleteSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	s.log.With(slog.String("operation", op))
 
-	s.log.Debug("start deleting song", slog.Int64("songID", id))
-	if err := s.provider.DeleteSong(id); err != nil {
-		s.log.Error("error deleting song", lg.Err(err))
+	s.log.DebugContext(ctx, "start deleting song", slog.Int64("songID", id))
+	if err := s.provider.DeleteSong(ctx, id, version, songChange(ctx, models.RevisionDelete)); err != nil {
+		s.log.ErrorContext(ctx, "error deleting song", lg.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	s.log.Debug("deleted song successfully", slog.Int64("songID", id))
+	s.log.DebugContext(ctx, "deleted song successfully", slog.Int64("songID", id))
 
 	return nil
 }
 
-func (s *Service) GetAllSongs(filter models.SongFilter, offset int, limit int) ([]models.SongResponse, error) {
+func (s *Service) GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error) {
 	const op = "services.song.GetAllSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
 	s.log.With(slog.String("operation", op))
-	s.log.Debug("start fetching songs with filters", slog.Any("filter", filter), slog.Int("offset", offset), slog.Int("limit", limit))
+	s.log.DebugContext(ctx, "start fetching songs with filters", slog.Any("filter", filter), slog.Int("limit", page.Limit))
 
-	var group models.Group
-	if filter.Group != "" {
-		group, err := s.GetGroupByName(filter.Group)
-		if err != nil {
-			if errors.Is(err, storage.ErrGroupNotFound) {
-				return nil, fmt.Errorf("%s: %w", op, err)
-			}
+	filter.Sort = withTieBreaker(filter.Sort)
 
-			s.log.Error("error during fetching group: ", lg.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, err)
+	var after *models.SongCursor
+	if page.Cursor != "" {
+		cursor, err := DecodeCursor(filter.Sort, page.Cursor)
+		if err != nil {
+			return models.SongPage{}, fmt.Errorf("%s: %w", op, err)
 		}
-		s.log.Debug("fetched group for filtering", slog.Any("group", group))
+		after = cursor
 	}
 
-	songs, err := s.provider.GetAllSongs(filter, group.ID, offset, limit)
+	songs, err := s.provider.GetAllSongs(ctx, filter, after, page.Limit+1)
 	if err != nil {
-		s.log.Error("error fetching songs", lg.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
+		s.log.ErrorContext(ctx, "error fetching songs", lg.Err(err))
+		return models.SongPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hasMore := len(songs) > page.Limit
+	if hasMore {
+		songs = songs[:page.Limit]
 	}
 
-	groups, err := s.fetchGroups(songs)
+	groups, err := s.fetchGroups(ctx, songs)
 	if err != nil {
-		s.log.Error("error fetching groups for songs", lg.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
+		s.log.ErrorContext(ctx, "error fetching groups for songs", lg.Err(err))
+		return models.SongPage{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var songResps []models.SongResponse
+	songResps := []models.SongResponse{}
 	for i := 0; i < len(songs); i++ {
 		songResps = append(songResps, SongToSongResp(songs[i], groups[i].Name))
 	}
 
-	s.log.Debug("fetched songs successfully", slog.Int("totalSongs", len(songResps)))
-	s.logSongsWithoutText(songs)
+	songPage := models.SongPage{
+		Songs:   songResps,
+		HasMore: hasMore,
+	}
+
+	if hasMore {
+		nextCursor, err := EncodeCursor(filter.Sort, songResps[len(songResps)-1])
+		if err != nil {
+			return models.SongPage{}, fmt.Errorf("%s: %w", op, err)
+		}
+		songPage.NextCursor = nextCursor
+	}
+
+	if page.WithTotal {
+		total, err := s.provider.CountSongs(ctx, filter)
+		if err != nil {
+			s.log.ErrorContext(ctx, "error counting songs", lg.Err(err))
+			return models.SongPage{}, fmt.Errorf("%s: %w", op, err)
+		}
+		songPage.Total = &total
+	}
+
+	s.log.DebugContext(ctx, "fetched songs successfully", slog.Int("totalSongs", len(songResps)), slog.Bool("hasMore", hasMore))
+	s.logSongsWithoutText(ctx, songs)
+
+	return songPage, nil
+}
+
+func (s *Service) SearchSongs(ctx context.Context, search models.SongSearch) ([]models.SongSearchHit, error) {
+	const op = "services.song.SearchSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.With(slog.String("operation", op))
+	s.log.DebugContext(ctx, "start searching songs", slog.String("query", search.Query), slog.String("language", search.Language))
+
+	hits, err := s.provider.SearchSongs(ctx, search)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error searching songs", lg.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-	return songResps, nil
+	s.log.DebugContext(ctx, "searched songs successfully", slog.Int("totalHits", len(hits)))
+	return hits, nil
 }
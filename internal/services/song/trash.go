@@ -0,0 +1,87 @@
+package song
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/models"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ListTrash returns a page of deleted songs and groups with the time the
+// purge job will remove them at.
+func (s *Service) ListTrash(ctx context.Context, offset int, limit int) ([]models.TrashItem, error) {
+	const op = "services.song.ListTrash"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start fetching trash", slog.Int("offset", offset), slog.Int("limit", limit))
+
+	items, err := s.provider.ListTrash(ctx, offset, limit)
+	if err != nil {
+		s.log.ErrorContext(ctx, "error fetching trash", lg.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.trash.Retention)
+	}
+
+	return items, nil
+}
+
+// RestoreSong takes the song out of the trash. Its group is restored with it
+// when it was deleted too.
+func (s *Service) RestoreSong(ctx context.Context, id int64) (models.SongResponse, error) {
+	const op = "services.song.RestoreSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start restoring song from trash", slog.Int64("songID", id))
+
+	if err := s.provider.UndeleteSong(ctx, id, songChange(ctx, models.RevisionRestore)); err != nil {
+		s.log.ErrorContext(ctx, "error restoring song from trash", lg.Err(err))
+		return models.SongResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.InfoContext(ctx, "restored song from trash", slog.Int64("songID", id))
+	return s.GetSongByID(ctx, id, false)
+}
+
+// RestoreGroup takes the group out of the trash together with the songs that
+// were deleted with it.
+func (s *Service) RestoreGroup(ctx context.Context, id int64) (models.GroupResponse, error) {
+	const op = "services.song.RestoreGroup"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	s.log.DebugContext(ctx, "start restoring group from trash", slog.Int64("groupID", id))
+
+	if err := s.provider.UndeleteGroup(ctx, id, songChange(ctx, models.RevisionRestore)); err != nil {
+		s.log.ErrorContext(ctx, "error restoring group from trash", lg.Err(err))
+		return models.GroupResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.InfoContext(ctx, "restored group from trash", slog.Int64("groupID", id))
+	return s.GetGroupByID(ctx, id, false)
+}
+
+// PurgeTrash removes everything deleted longer than the retention ago.
+func (s *Service) PurgeTrash(ctx context.Context) (models.PurgeResult, error) {
+	const op = "services.song.PurgeTrash"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	result, err := s.provider.PurgeTrash(ctx, time.Now().Add(-s.trash.Retention))
+	if err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.Songs > 0 || result.Groups > 0 {
+		s.log.InfoContext(ctx, "purged trash", slog.Int64("songs", result.Songs), slog.Int64("groups", result.Groups))
+	}
+
+	return result, nil
+}
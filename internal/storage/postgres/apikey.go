@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/domain/principal"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const apiKeyColumns = "id, name, prefix, created_by, created_at, revoked_at"
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var revokedAt sql.NullTime
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.CreatedBy, &key.CreatedAt, &revokedAt); err != nil {
+		return models.APIKey{}, err
+	}
+
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
+
+// CreateAPIKey stores a key by the hash of its secret and grants roles to
+// the key ID.
+func (s *Storage) CreateAPIKey(ctx context.Context, key models.APIKey, hash string, roles []string) (models.APIKey, error) {
+	const op = "storage.postgres.CreateAPIKey"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx,
+		`INSERT INTO api_keys(name, prefix, key_hash, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+apiKeyColumns,
+		key.Name, key.Prefix, hash, key.CreatedBy,
+	)
+
+	created, err := scanAPIKey(row)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, role := range roles {
+		if err := grantRole(ctx, tx, principal.KeyGrantSubject(created.ID), role); err != nil {
+			return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// GetAPIKeyByHash returns the unrevoked key with the given secret hash.
+func (s *Storage) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	const op = "storage.postgres.GetAPIKeyByHash"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL", hash)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, storage.ErrAPIKeyNotFound
+		}
+
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context, offset int, limit int) ([]models.APIKey, error) {
+	const op = "storage.postgres.ListAPIKeys"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id OFFSET $1 LIMIT $2", offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey stops the key from authenticating and drops its grants.
+// Revoked keys stay listed.
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int64) error {
+	const op = "storage.postgres.RevokeAPIKey"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAPIKeyNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_grants WHERE subject = $1", principal.KeyGrantSubject(id)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"fmt"
+	"time"
+)
+
+// SyncRoles makes the stored roles match roles, dropping the ones no longer
+// configured together with their grants, and adds grants. Grants made by
+// other means are kept.
+func (s *Storage) SyncRoles(ctx context.Context, roles map[string][]string, grants map[string][]string) error {
+	const op = "storage.postgres.SyncRoles"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	stored, err := tx.QueryContext(ctx, "SELECT name FROM roles")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var stale []string
+	for stored.Next() {
+		var name string
+		if err := stored.Scan(&name); err != nil {
+			stored.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if _, ok := roles[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+	stored.Close()
+
+	if err := stored.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, name := range stale {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM roles WHERE name = $1", name); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for name, permissions := range roles {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO roles(name) VALUES ($1) ON CONFLICT DO NOTHING", name); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role = $1", name); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, permission := range permissions {
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO role_permissions(role, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				name, permission,
+			)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	for subject, subjectRoles := range grants {
+		for _, role := range subjectRoles {
+			if err := grantRole(ctx, tx, subject, role); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// grantRole gives the subject an existing role.
+func grantRole(ctx context.Context, tx *sql.Tx, subject string, role string) error {
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO role_grants(subject, role)
+		SELECT $1, name FROM roles WHERE name = $2
+		ON CONFLICT DO NOTHING`,
+		subject, role,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to fetch affected rows: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)", role).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return storage.ErrRoleNotFound.Withf("%q", role)
+	}
+
+	return nil
+}
+
+// GetRoles returns the permissions of every stored role.
+func (s *Storage) GetRoles(ctx context.Context) (map[string][]string, error) {
+	const op = "storage.postgres.GetRoles"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT r.name, p.permission
+		FROM roles r
+		LEFT JOIN role_permissions p ON p.role = r.name
+		ORDER BY r.name, p.permission`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	roles := map[string][]string{}
+	for rows.Next() {
+		var name string
+		var permission sql.NullString
+
+		if err := rows.Scan(&name, &permission); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, ok := roles[name]; !ok {
+			roles[name] = []string{}
+		}
+		if permission.Valid {
+			roles[name] = append(roles[name], permission.String)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// GetSubjectRoles returns the roles granted to the subject.
+func (s *Storage) GetSubjectRoles(ctx context.Context, subject string) ([]string, error) {
+	const op = "storage.postgres.GetSubjectRoles"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT role FROM role_grants WHERE subject = $1 ORDER BY role", subject)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+func (s *Storage) RecordDenial(ctx context.Context, denial models.Denial) error {
+	const op = "storage.postgres.RecordDenial"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO authz_denials(subject, permission, operation) VALUES ($1, $2, $3)",
+		denial.Subject, denial.Permission, denial.Operation,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PurgeDenials removes the denials recorded before the given time.
+func (s *Storage) PurgeDenials(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.postgres.PurgeDenials"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM authz_denials WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	return purged, nil
+}
+
+// ListDenials returns the recorded denials, newest first.
+func (s *Storage) ListDenials(ctx context.Context, offset int, limit int) ([]models.Denial, error) {
+	const op = "storage.postgres.ListDenials"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, subject, permission, operation, created_at
+		FROM authz_denials
+		ORDER BY id DESC
+		OFFSET $1 LIMIT $2`,
+		offset, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	denials := []models.Denial{}
+	for rows.Next() {
+		var denial models.Denial
+		if err := rows.Scan(&denial.ID, &denial.Subject, &denial.Permission, &denial.Operation, &denial.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		denials = append(denials, denial)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return denials, nil
+}
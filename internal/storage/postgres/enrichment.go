@@ -0,0 +1,297 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/actor"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/lyrics"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EnqueueEnrichment (re)queues the enrichment job of the song and marks the
+// song as pending again.
+func (s *Storage) EnqueueEnrichment(ctx context.Context, songID int64) error {
+	const op = "storage.postgres.EnqueueEnrichment"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE songs SET enrichment_status = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL",
+		models.EnrichmentPending, songID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrSongNotFound
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO enrichment_jobs(song_id) VALUES ($1)
+		ON CONFLICT (song_id) DO UPDATE
+		SET status = 'queued', attempts = 0, last_error = '', run_at = now(),
+			locked_until = NULL, updated_at = now()`,
+		songID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClaimEnrichmentJobs leases up to limit due jobs to the caller. Jobs whose
+// lease expired (the worker died mid-run) are claimed again. SKIP LOCKED lets
+// several workers and app instances poll the table concurrently.
+func (s *Storage) ClaimEnrichmentJobs(ctx context.Context, limit int, lease time.Duration) ([]models.EnrichmentJob, error) {
+	const op = "storage.postgres.ClaimEnrichmentJobs"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE enrichment_jobs
+		SET status = 'running', attempts = attempts + 1,
+			locked_until = now() + make_interval(secs => $2), updated_at = now()
+		WHERE id IN (
+			SELECT id FROM enrichment_jobs
+			WHERE ((status = 'queued' AND run_at <= now())
+				OR (status = 'running' AND locked_until < now()))
+				AND song_id IN (SELECT id FROM songs WHERE deleted_at IS NULL)
+			ORDER BY run_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, song_id, attempts`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var jobs []models.EnrichmentJob
+	for rows.Next() {
+		var job models.EnrichmentJob
+		if err := rows.Scan(&job.ID, &job.SongID, &job.Attempts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+// CompleteEnrichment fills in the song details that are still empty, so
+// anything a user set while the job was running is kept, and closes the job.
+// When the text is filled in, the lyrics sections are built from it in the
+// same transaction. A worker whose lease expired gets
+// ErrEnrichmentLeaseLost and changes nothing, the job belongs to whoever
+// reclaimed it.
+func (s *Storage) CompleteEnrichment(ctx context.Context, job models.EnrichmentJob, detail models.SongDetail) error {
+	const op = "storage.postgres.CompleteEnrichment"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE enrichment_jobs
+		SET status = 'done', last_error = '', locked_until = NULL, updated_at = now()
+		WHERE id = $1 AND status = 'running' AND locked_until >= now()`,
+		job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := leaseHeld(res); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var text string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(text, '') FROM songs WHERE id = $1 FOR UPDATE", job.SongID).Scan(&text)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE songs
+		SET release_date = COALESCE(release_date, $2),
+			text = CASE WHEN COALESCE(text, '') = '' THEN $3 ELSE text END,
+			link = CASE WHEN COALESCE(link, '') = '' THEN $4 ELSE link END,
+			enrichment_status = $5,
+			version = version + 1
+		WHERE id = $1`,
+		job.SongID, nullDate(detail.ReleaseDate), detail.Text, detail.Link, models.EnrichmentEnriched,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if text == "" && detail.Text != "" {
+		if err := replaceSections(ctx, tx, job.SongID, lyrics.FromText(detail.Text)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	change := models.SongChange{Action: models.RevisionUpdate, Actor: actor.System}
+	if err := recordRevision(ctx, tx, job.SongID, change); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FailEnrichment records the error of the attempt. With a retryAt the job is
+// queued again, without it the job and the song are marked as failed. Like
+// CompleteEnrichment it returns ErrEnrichmentLeaseLost once the lease
+// expired.
+func (s *Storage) FailEnrichment(ctx context.Context, job models.EnrichmentJob, errMsg string, retryAt *time.Time) error {
+	const op = "storage.postgres.FailEnrichment"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var res sql.Result
+	if retryAt != nil {
+		res, err = tx.ExecContext(ctx,
+			`UPDATE enrichment_jobs
+			SET status = 'queued', last_error = $2, run_at = $3, locked_until = NULL, updated_at = now()
+			WHERE id = $1 AND status = 'running' AND locked_until >= now()`,
+			job.ID, errMsg, *retryAt,
+		)
+	} else {
+		res, err = tx.ExecContext(ctx,
+			`UPDATE enrichment_jobs
+			SET status = 'failed', last_error = $2, locked_until = NULL, updated_at = now()
+			WHERE id = $1 AND status = 'running' AND locked_until >= now()`,
+			job.ID, errMsg,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := leaseHeld(res); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if retryAt == nil {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE songs SET enrichment_status = $1, version = version + 1 WHERE id = $2",
+			models.EnrichmentFailed, job.SongID,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetEnrichment(ctx context.Context, songID int64) (models.Enrichment, error) {
+	const op = "storage.postgres.GetEnrichment"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var enrichment models.Enrichment
+	var attempts sql.NullInt64
+	var lastError, jobStatus sql.NullString
+	var runAt, updatedAt sql.NullTime
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT s.id, s.enrichment_status, j.status, j.attempts, j.last_error, j.run_at, j.updated_at
+		FROM songs s
+		LEFT JOIN enrichment_jobs j ON j.song_id = s.id
+		WHERE s.id = $1 AND s.deleted_at IS NULL`,
+		songID,
+	).Scan(&enrichment.SongID, &enrichment.Status, &jobStatus, &attempts, &lastError, &runAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Enrichment{}, storage.ErrSongNotFound
+		}
+
+		return models.Enrichment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	enrichment.Attempts = int(attempts.Int64)
+	enrichment.LastError = lastError.String
+	if jobStatus.String == "queued" && runAt.Valid {
+		enrichment.NextRunAt = &runAt.Time
+	}
+	if updatedAt.Valid {
+		enrichment.UpdatedAt = &updatedAt.Time
+	}
+
+	return enrichment, nil
+}
+
+// leaseHeld returns ErrEnrichmentLeaseLost when the job update matched no
+// row: the lease expired and the job was reclaimed or already closed.
+func leaseHeld(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to fetch affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrEnrichmentLeaseLost
+	}
+
+	return nil
+}
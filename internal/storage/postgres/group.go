@@ -1,17 +1,26 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"effectivemobiletesttask/internal/domain/models"
 	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
-func (s *Storage) CreateGroup(groupName string) (int64, error) {
+func (s *Storage) CreateGroup(ctx context.Context, groupName string) (int64, error) {
 	const op = "storage.postgres.CreateGroup"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	stmt, err := s.db.Prepare("INSERT INTO groups(name) VALUES ($1) RETURNING id")
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO groups(name) VALUES ($1) ON CONFLICT (name) WHERE deleted_at IS NULL DO NOTHING RETURNING id")
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -19,27 +28,37 @@ func (s *Storage) CreateGroup(groupName string) (int64, error) {
 
 	var id int64
 
-	err = stmt.QueryRow(groupName).Scan(&id)
+	err = stmt.QueryRowContext(ctx, groupName).Scan(&id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrGroupExists)
+		}
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return id, nil
 }
 
-func (s *Storage) GetGroupByID(id int64) (models.Group, error) {
+// GetGroupByID returns the group. Groups in the trash are only returned with
+// includeDeleted.
+func (s *Storage) GetGroupByID(ctx context.Context, id int64, includeDeleted bool) (models.Group, error) {
 	const op = "storage.postgres.GetGroupByID"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	stmt, err := s.db.Prepare("SELECT * FROM groups WHERE id = $1")
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, name, deleted_at FROM groups WHERE id = $1 AND ($2 OR deleted_at IS NULL)")
 	if err != nil {
 		return models.Group{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(id)
+	row := stmt.QueryRowContext(ctx, id, includeDeleted)
 
 	var group models.Group
-	err = row.Scan(&group.ID, &group.Name)
+	var deletedAt sql.NullTime
+	err = row.Scan(&group.ID, &group.Name, &deletedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.Group{}, fmt.Errorf("%s: %w", op, storage.ErrGroupNotFound)
@@ -47,19 +66,27 @@ func (s *Storage) GetGroupByID(id int64) (models.Group, error) {
 		return models.Group{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if deletedAt.Valid {
+		group.DeletedAt = &deletedAt.Time
+	}
+
 	return group, nil
 }
 
-func (s *Storage) GetGroupByName(groupName string) (models.Group, error) {
+func (s *Storage) GetGroupByName(ctx context.Context, groupName string) (models.Group, error) {
 	const op = "storage.postgres.GetGroupByName"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	stmt, err := s.db.Prepare("SELECT * FROM groups WHERE name = $1")
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, name FROM groups WHERE name = $1 AND deleted_at IS NULL")
 	if err != nil {
 		return models.Group{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(groupName)
+	row := stmt.QueryRowContext(ctx, groupName)
 
 	var group models.Group
 
@@ -73,3 +100,277 @@ func (s *Storage) GetGroupByName(groupName string) (models.Group, error) {
 
 	return group, nil
 }
+
+func (s *Storage) GetAllGroups(ctx context.Context, filter models.GroupFilter, offset int, limit int) ([]models.GroupResponse, error) {
+	const op = "storage.postgres.GetAllGroups"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT g.id, g.name, COUNT(s.id), g.deleted_at
+		FROM groups g
+		LEFT JOIN songs s ON s.group_id = g.id AND s.deleted_at IS NULL`
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "g.deleted_at IS NULL")
+	}
+	if filter.Name != "" {
+		args = append(args, "%"+escapeLike(filter.Name)+"%")
+		conditions = append(conditions, "g.name ILIKE $"+fmt.Sprint(len(args)))
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, offset, limit)
+	query += " GROUP BY g.id, g.name, g.deleted_at ORDER BY g.name, g.id"
+	query += " OFFSET $" + fmt.Sprint(len(args)-1) + " LIMIT $" + fmt.Sprint(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	groups := []models.GroupResponse{}
+	for rows.Next() {
+		var group models.GroupResponse
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&group.ID, &group.Name, &group.SongsCount, &deletedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if deletedAt.Valid {
+			group.DeletedAt = &deletedAt.Time
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return groups, nil
+}
+
+func (s *Storage) CountGroupSongs(ctx context.Context, id int64) (int64, error) {
+	const op = "storage.postgres.CountGroupSongs"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT COUNT(*) FROM songs WHERE group_id = $1 AND deleted_at IS NULL")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var count int64
+
+	if err := stmt.QueryRowContext(ctx, id).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// UpdateGroup renames the group. The group name is part of every song of
+// the group, so their versions are bumped too, their ETags change and each
+// of them gets a revision. Renaming to the name of another live group
+// returns storage.ErrGroupExists.
+func (s *Storage) UpdateGroup(ctx context.Context, id int64, groupName string, change models.SongChange) (models.Group, error) {
+	const op = "storage.postgres.UpdateGroup"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var group models.Group
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE groups SET name = $1 WHERE id = $2 AND deleted_at IS NULL RETURNING id, name",
+		groupName, id,
+	).Scan(&group.ID, &group.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Group{}, fmt.Errorf("%s: %w", op, storage.ErrGroupNotFound)
+		}
+		if isUniqueViolation(err, "idx_groups_live_name") {
+			return models.Group{}, fmt.Errorf("%s: %w", op, storage.ErrGroupExists)
+		}
+		return models.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songIDs, err := queryIDs(ctx, tx, "UPDATE songs SET version = version + 1 WHERE group_id = $1 RETURNING id", id)
+	if err != nil {
+		return models.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, songID := range songIDs {
+		if err := recordRevision(ctx, tx, songID, change); err != nil {
+			return models.Group{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return group, nil
+}
+
+// MergeGroups moves all songs of the source group, including those in the
+// trash, to the target group and moves the source group to the trash. Each
+// moved song gets a revision. The target is locked for the transaction, so
+// it can't be moved to the trash while the songs move in.
+func (s *Storage) MergeGroups(ctx context.Context, sourceID int64, targetID int64, change models.SongChange) error {
+	const op = "storage.postgres.MergeGroups"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var target int64
+	err = tx.QueryRowContext(ctx, "SELECT id FROM groups WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", targetID).Scan(&target)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrGroupNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE groups SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", sourceID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrGroupNotFound)
+	}
+
+	songIDs, err := queryIDs(ctx, tx,
+		"UPDATE songs SET group_id = $1, version = version + 1 WHERE group_id = $2 RETURNING id", targetID, sourceID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, songID := range songIDs {
+		if err := recordRevision(ctx, tx, songID, change); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteGroup moves the group to the trash. With cascade its live songs go to
+// the trash with it and share its deletion time, so that restoring the group
+// brings exactly them back. Each of them gets a delete revision.
+func (s *Storage) DeleteGroup(ctx context.Context, id int64, cascade bool, change models.SongChange) error {
+	const op = "storage.postgres.DeleteGroup"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE groups SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrGroupNotFound)
+	}
+
+	songIDs, err := liveGroupSongs(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(songIDs) > 0 && !cascade {
+		return fmt.Errorf("%s: %w", op, storage.ErrGroupHasSongs)
+	}
+
+	for _, songID := range songIDs {
+		if err := recordRevision(ctx, tx, songID, change); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// now() is the start of the transaction, so the songs get exactly the
+	// deletion time of the group.
+	_, err = tx.ExecContext(ctx,
+		"UPDATE songs SET deleted_at = now(), version = version + 1 WHERE group_id = $1 AND deleted_at IS NULL", id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func liveGroupSongs(ctx context.Context, tx *sql.Tx, groupID int64) ([]int64, error) {
+	return queryIDs(ctx, tx, "SELECT id FROM songs WHERE group_id = $1 AND deleted_at IS NULL ORDER BY id FOR UPDATE", groupID)
+}
+
+// queryIDs runs a query returning a single id column and reads all of it, so
+// that tx is free for the next statement.
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) GetLyrics(ctx context.Context, songID int64) (models.Lyrics, error) {
+	const op = "storage.postgres.GetLyrics"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var exists bool
+
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL)", songID).Scan(&exists)
+	if err != nil {
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !exists {
+		return models.Lyrics{}, storage.ErrSongNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT sec.id, sec.type, l.text, l.start_ms
+		FROM lyrics_sections sec
+		LEFT JOIN lyrics_lines l ON l.section_id = sec.id
+		WHERE sec.song_id = $1
+		ORDER BY sec.position, l.position`, songID)
+	if err != nil {
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	lyrics := models.Lyrics{SongID: songID, Sections: []models.LyricsSection{}}
+	var lastSectionID int64
+
+	for rows.Next() {
+		var sectionID int64
+		var sectionType string
+		var text sql.NullString
+		var startMs sql.NullInt64
+
+		if err := rows.Scan(&sectionID, &sectionType, &text, &startMs); err != nil {
+			return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if sectionID != lastSectionID {
+			lyrics.Sections = append(lyrics.Sections, models.LyricsSection{Type: sectionType, Lines: []models.LyricsLine{}})
+			lastSectionID = sectionID
+		}
+
+		if !text.Valid {
+			continue
+		}
+
+		line := models.LyricsLine{Text: text.String}
+		if startMs.Valid {
+			line.StartMs = &startMs.Int64
+		}
+
+		section := &lyrics.Sections[len(lyrics.Sections)-1]
+		section.Lines = append(section.Lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lyrics, nil
+}
+
+// SaveLyrics replaces all sections of the song and updates its plain text
+// in one transaction, so both representations never diverge. A revision is
+// recorded when change is not nil.
+func (s *Storage) SaveLyrics(ctx context.Context, songID int64, sections []models.LyricsSection, text string, change *models.SongChange) error {
+	const op = "storage.postgres.SaveLyrics"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE songs SET text = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL", text, songID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrSongNotFound
+	}
+
+	if err := replaceSections(ctx, tx, songID, sections); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if change != nil {
+		if err := recordRevision(ctx, tx, songID, *change); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// replaceSections replaces all lyrics sections of the song. Callers update
+// songs.text in the same transaction.
+func replaceSections(ctx context.Context, tx *sql.Tx, songID int64, sections []models.LyricsSection) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM lyrics_sections WHERE song_id = $1", songID); err != nil {
+		return err
+	}
+
+	sectionStmt, err := tx.PrepareContext(ctx, "INSERT INTO lyrics_sections(song_id, position, type) VALUES ($1, $2, $3) RETURNING id")
+	if err != nil {
+		return err
+	}
+	defer sectionStmt.Close()
+
+	lineStmt, err := tx.PrepareContext(ctx, "INSERT INTO lyrics_lines(section_id, position, text, start_ms) VALUES ($1, $2, $3, $4)")
+	if err != nil {
+		return err
+	}
+	defer lineStmt.Close()
+
+	for i, section := range sections {
+		var sectionID int64
+
+		if err := sectionStmt.QueryRowContext(ctx, songID, i, section.Type).Scan(&sectionID); err != nil {
+			return err
+		}
+
+		for j, line := range section.Lines {
+			if _, err := lineStmt.ExecContext(ctx, sectionID, j, line.Text, line.StartMs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
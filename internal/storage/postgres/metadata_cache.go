@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const metadataCacheColumns = "key, group_name, song_name, found, release_date, text, link, expires_at"
+
+func scanMetadataCacheEntry(row rowScanner) (models.MetadataCacheEntry, error) {
+	var entry models.MetadataCacheEntry
+	var releaseDate sql.NullTime
+	var text, link string
+
+	err := row.Scan(
+		&entry.Key, &entry.Group, &entry.Song, &entry.Found, &releaseDate, &text, &link, &entry.ExpiresAt,
+	)
+	if err != nil {
+		return models.MetadataCacheEntry{}, err
+	}
+
+	if entry.Found {
+		entry.Detail = &models.SongDetail{ReleaseDate: releaseDate.Time, Text: text, Link: link}
+	}
+	entry.Tier = models.CacheTierPersistent
+
+	return entry, nil
+}
+
+func (s *Storage) GetMetadataCache(ctx context.Context, key string) (models.MetadataCacheEntry, error) {
+	const op = "storage.postgres.GetMetadataCache"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+metadataCacheColumns+" FROM metadata_cache WHERE key = $1 AND expires_at > now()",
+		key,
+	)
+
+	entry, err := scanMetadataCacheEntry(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MetadataCacheEntry{}, storage.ErrCacheEntryNotFound
+		}
+
+		return models.MetadataCacheEntry{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entry, nil
+}
+
+func (s *Storage) SaveMetadataCache(ctx context.Context, entry models.MetadataCacheEntry) error {
+	const op = "storage.postgres.SaveMetadataCache"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var detail models.SongDetail
+	if entry.Detail != nil {
+		detail = *entry.Detail
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO metadata_cache(`+metadataCacheColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (key) DO UPDATE
+		SET found = EXCLUDED.found, release_date = EXCLUDED.release_date, text = EXCLUDED.text,
+			link = EXCLUDED.link, expires_at = EXCLUDED.expires_at, created_at = now()`,
+		entry.Key, entry.Group, entry.Song, entry.Found,
+		nullDate(detail.ReleaseDate), detail.Text, detail.Link, entry.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ListMetadataCache(ctx context.Context, offset int, limit int) ([]models.MetadataCacheEntry, error) {
+	const op = "storage.postgres.ListMetadataCache"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+metadataCacheColumns+` FROM metadata_cache
+		WHERE expires_at > now()
+		ORDER BY created_at DESC, key
+		OFFSET $1 LIMIT $2`,
+		offset, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	entries := []models.MetadataCacheEntry{}
+	for rows.Next() {
+		entry, err := scanMetadataCacheEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+func (s *Storage) DeleteMetadataCache(ctx context.Context, key string) error {
+	const op = "storage.postgres.DeleteMetadataCache"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM metadata_cache WHERE key = $1", key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrCacheEntryNotFound
+	}
+
+	return nil
+}
+
+// PurgeMetadataCache removes every entry, expired or not, and returns how
+// many rows were deleted.
+func (s *Storage) PurgeMetadataCache(ctx context.Context) (int64, error) {
+	const op = "storage.postgres.PurgeMetadataCache"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM metadata_cache")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// PurgeExpiredMetadataCache removes the entries past their expiry, which
+// reads already skip, and returns how many rows were deleted.
+func (s *Storage) PurgeExpiredMetadataCache(ctx context.Context) (int64, error) {
+	const op = "storage.postgres.PurgeExpiredMetadataCache"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM metadata_cache WHERE expires_at <= now()")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rowsAffected, nil
+}
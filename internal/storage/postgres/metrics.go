@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const inventoryTimeout = 5 * time.Second
+
+// Collectors returns the metrics of the storage: the connection pool stats
+// and the number of songs and groups, counted on every scrape.
+func (s *Storage) Collectors(dbName string) []prometheus.Collector {
+	return []prometheus.Collector{
+		collectors.NewDBStatsCollector(s.db, dbName),
+		metrics.NewInventoryCollector(s, inventoryTimeout),
+	}
+}
+
+func (s *Storage) CountInventory(ctx context.Context) (metrics.Inventory, error) {
+	const op = "storage.postgres.CountInventory"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var liveSongs, trashedSongs, liveGroups, trashedGroups int64
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT
+			(SELECT count(*) FROM songs WHERE deleted_at IS NULL),
+			(SELECT count(*) FROM songs WHERE deleted_at IS NOT NULL),
+			(SELECT count(*) FROM groups WHERE deleted_at IS NULL),
+			(SELECT count(*) FROM groups WHERE deleted_at IS NOT NULL)`,
+	).Scan(&liveSongs, &trashedSongs, &liveGroups, &trashedGroups)
+	if err != nil {
+		return metrics.Inventory{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return metrics.Inventory{
+		Songs:  map[string]int64{metrics.StateLive: liveSongs, metrics.StateTrash: trashedSongs},
+		Groups: map[string]int64{metrics.StateLive: liveGroups, metrics.StateTrash: trashedGroups},
+	}, nil
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/ratelimit"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TakeToken takes a token from the bucket stored under key. The bucket row is
+// locked while it is refilled, and the clock of the database is used, so
+// that replicas share the bucket consistently.
+func (s *Storage) TakeToken(ctx context.Context, key string, bucket ratelimit.Bucket) (ratelimit.Result, error) {
+	const op = "storage.postgres.TakeToken"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO rate_limits(key, tokens, updated_at) VALUES ($1, $2, now()) ON CONFLICT DO NOTHING",
+		key, bucket.Burst,
+	)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var state ratelimit.State
+	var now time.Time
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT tokens, updated_at, now() FROM rate_limits WHERE key = $1 FOR UPDATE",
+		key,
+	).Scan(&state.Tokens, &state.UpdatedAt, &now)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	state, res := bucket.Take(state, now)
+
+	_, err = tx.ExecContext(ctx, "UPDATE rate_limits SET tokens = $2, updated_at = $3 WHERE key = $1", key, state.Tokens, state.UpdatedAt)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// PeekToken reports whether the bucket stored under key has a token, by the
+// clock of the database, without taking it. A missing bucket is full.
+func (s *Storage) PeekToken(ctx context.Context, key string, bucket ratelimit.Bucket) (ratelimit.Result, error) {
+	const op = "storage.postgres.PeekToken"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var state ratelimit.State
+	var now time.Time
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT tokens, updated_at, now() FROM rate_limits WHERE key = $1",
+		key,
+	).Scan(&state.Tokens, &state.UpdatedAt, &now)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return bucket.Peek(ratelimit.State{}, time.Now()), nil
+		}
+		return ratelimit.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bucket.Peek(state, now), nil
+}
+
+// PurgeRateLimits drops buckets unused for longer than idle.
+func (s *Storage) PurgeRateLimits(ctx context.Context, idle time.Duration) (int64, error) {
+	const op = "storage.postgres.PurgeRateLimits"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM rate_limits WHERE updated_at < now() - make_interval(secs => $1)",
+		idle.Seconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/lyrics"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// recordRevision snapshots the song as tx sees it now. Writes call it in
+// their own transaction, after the change or, for deletes, before it, so a
+// revision is recorded exactly when the write commits.
+func recordRevision(ctx context.Context, tx *sql.Tx, songID int64, change models.SongChange) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO song_revisions(song_id, revision, action, actor, restored_from, snapshot)
+		SELECT s.id,
+			COALESCE((SELECT MAX(r.revision) FROM song_revisions r WHERE r.song_id = s.id), 0) + 1,
+			$2, $3, NULLIF($4::int, 0),
+			jsonb_build_object(
+				'song', s.name,
+				'group', COALESCE(g.name, ''),
+				'releaseDate', COALESCE(to_char(s.release_date, 'YYYY-MM-DD'), ''),
+				'text', COALESCE(s.text, ''),
+				'link', COALESCE(s.link, '')
+			)
+		FROM songs s
+		LEFT JOIN groups g ON g.id = s.group_id
+		WHERE s.id = $1`,
+		songID, change.Action, change.Actor, change.RestoredFrom,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording song revision: %w", err)
+	}
+
+	return nil
+}
+
+const revisionColumns = "song_id, revision, action, actor, restored_from, snapshot, created_at"
+
+func scanRevision(row rowScanner) (models.SongRevision, error) {
+	var revision models.SongRevision
+	var restoredFrom sql.NullInt64
+	var snapshot []byte
+
+	err := row.Scan(
+		&revision.SongID, &revision.Revision, &revision.Action, &revision.Actor, &restoredFrom, &snapshot, &revision.CreatedAt,
+	)
+	if err != nil {
+		return models.SongRevision{}, err
+	}
+
+	if restoredFrom.Valid {
+		revision.RestoredFrom = &restoredFrom.Int64
+	}
+
+	if err := json.Unmarshal(snapshot, &revision.Snapshot); err != nil {
+		return models.SongRevision{}, err
+	}
+
+	return revision, nil
+}
+
+// ListSongRevisions returns a page of the revisions of the song, newest first.
+func (s *Storage) ListSongRevisions(ctx context.Context, songID int64, offset int, limit int) ([]models.SongRevision, error) {
+	const op = "storage.postgres.ListSongRevisions"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+revisionColumns+" FROM song_revisions WHERE song_id = $1 ORDER BY revision DESC OFFSET $2 LIMIT $3",
+		songID, offset, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions := []models.SongRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+func (s *Storage) GetSongRevision(ctx context.Context, songID int64, revision int64) (models.SongRevision, error) {
+	const op = "storage.postgres.GetSongRevision"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+revisionColumns+" FROM song_revisions WHERE song_id = $1 AND revision = $2",
+		songID, revision,
+	)
+
+	songRevision, err := scanRevision(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SongRevision{}, storage.ErrRevisionNotFound
+		}
+
+		return models.SongRevision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songRevision, nil
+}
+
+// RestoreSong writes a restored snapshot back. A purged song is inserted
+// again under its old id, a song in the trash is taken out of it. An existing
+// song is overwritten, conditionally on song.Version when it is not zero. The
+// lyrics sections are rebuilt from the restored text in the same transaction.
+func (s *Storage) RestoreSong(ctx context.Context, id int64, song models.SongStorage, change models.SongChange) (models.SongStorage, error) {
+	const op = "storage.postgres.RestoreSong"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO songs AS s (id, group_id, name, release_date, text, link, enrichment_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE
+		SET group_id = EXCLUDED.group_id, name = EXCLUDED.name, release_date = EXCLUDED.release_date,
+			text = EXCLUDED.text, link = EXCLUDED.link, version = s.version + 1, deleted_at = NULL
+		WHERE $8::bigint = 0 OR s.version = $8
+		RETURNING s.version`,
+		id, song.GroupID, song.Name, nullDate(song.ReleaseDate), song.Text, song.Link, models.EnrichmentEnriched, song.Version,
+	).Scan(&song.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SongStorage{}, storage.ErrSongModified
+		}
+
+		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := replaceSections(ctx, tx, id, lyrics.FromText(song.Text)); err != nil {
+		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, id, change); err != nil {
+		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	song.ID = id
+
+	return song, nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"fmt"
+	"time"
+)
+
+type searchConfig struct {
+	regconfig string
+	column    string
+}
+
+// searchConfigs maps supported search languages to the text search
+// configuration and the generated tsvector column built with it.
+var searchConfigs = map[string]searchConfig{
+	"russian": {regconfig: "russian", column: "s.text_tsv_russian"},
+	"english": {regconfig: "english", column: "s.text_tsv_english"},
+}
+
+// SearchSongs runs a full-text search over song lyrics. Each hit carries the
+// best matching verse highlighted with ts_headline; when the query matches the
+// song as a whole but no single verse, the headline of the full text is used.
+// Verses are split like lyrics.SplitVerses and migration 3, so the verse of a
+// hit is the one the song text endpoint returns at that offset.
+func (s *Storage) SearchSongs(ctx context.Context, search models.SongSearch) ([]models.SongSearchHit, error) {
+	const op = "storage.postgres.SearchSongs"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	config, ok := searchConfigs[search.Language]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported search language %q", op, search.Language)
+	}
+
+	query := `SELECT s.id, s.name, COALESCE(g.name, ''), s.release_date, s.link,
+			ts_rank(` + config.column + `, q) AS rank,
+			hit.verse,
+			COALESCE(hit.snippet, ts_headline($1::regconfig, s.text, q, $2))
+		FROM songs s
+		LEFT JOIN groups g ON g.id = s.group_id
+		CROSS JOIN websearch_to_tsquery($1::regconfig, $3) AS q
+		LEFT JOIN LATERAL (
+			SELECT v.ord - 1 AS verse, ts_headline($1::regconfig, v.body, q, $2) AS snippet
+			FROM regexp_split_to_table(
+				btrim(replace(s.text, E'\r\n', E'\n'), E' \t\n'), E'\n([ \t]*\n)+'
+			) WITH ORDINALITY AS v(body, ord)
+			WHERE to_tsvector($1::regconfig, v.body) @@ q
+			ORDER BY ts_rank(to_tsvector($1::regconfig, v.body), q) DESC, v.ord
+			LIMIT 1
+		) hit ON true
+		WHERE s.deleted_at IS NULL AND ` + config.column + ` @@ q
+		ORDER BY rank DESC, s.id
+		OFFSET $4 LIMIT $5`
+
+	const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=40, MinWords=10, HighlightAll=false"
+
+	rows, err := s.db.QueryContext(ctx, query, config.regconfig, headlineOptions, search.Query, search.Offset, search.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	hits := []models.SongSearchHit{}
+	for rows.Next() {
+		var hit models.SongSearchHit
+		var verse *int
+		var releaseDate sql.NullTime
+
+		err := rows.Scan(
+			&hit.ID, &hit.Name, &hit.Group, &releaseDate, &hit.Link, &hit.Rank, &verse, &hit.Snippet,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hit.Verse = verse
+		hit.ReleaseDate = releaseDate.Time
+
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hits, nil
+}
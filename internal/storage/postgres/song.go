@@ -1,49 +1,83 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"effectivemobiletesttask/internal/domain/models"
 	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/lyrics"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
-func (s *Storage) CreateSong(song models.SongStorage) (int64, error) {
+// CreateSong inserts the song and records its first revision. When the song
+// still awaits enrichment, the enrichment job is queued in the same
+// transaction so a song is never left pending without a job to pick it up.
+func (s *Storage) CreateSong(ctx context.Context, song models.SongStorage, change models.SongChange) (int64, error) {
 	const op = "storage.postgres.CreateSong"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	stmt, err := s.db.Prepare(
-		"INSERT INTO songs(group_id, name, release_date, text, link) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-	)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if song.EnrichmentStatus == "" {
+		song.EnrichmentStatus = models.EnrichmentEnriched
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
 	var id int64
 
-	err = stmt.QueryRow(song.GroupID, song.Name, song.ReleaseDate, song.Text, song.Link).Scan(&id)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO songs(group_id, name, release_date, text, link, enrichment_status)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		song.GroupID, song.Name, nullDate(song.ReleaseDate), song.Text, song.Link, song.EnrichmentStatus,
+	).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if song.EnrichmentStatus == models.EnrichmentPending {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO enrichment_jobs(song_id) VALUES ($1)", id); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, id, change); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return id, nil
 }
 
-func (s *Storage) GetSongByID(id int64) (models.SongStorage, error) {
+// GetSongByID returns the song. Songs in the trash are only returned with
+// includeDeleted.
+func (s *Storage) GetSongByID(ctx context.Context, id int64, includeDeleted bool) (models.SongStorage, error) {
 	const op = "storage.postgres.GetSongByID"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	stmt, err := s.db.Prepare("SELECT * FROM songs WHERE id = $1")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT "+songColumns+" FROM songs WHERE id = $1 AND ($2 OR deleted_at IS NULL)")
 	if err != nil {
 		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(id)
-
-	var song models.SongStorage
-
-	err = row.Scan(&song.ID, &song.Name, &song.GroupID, &song.ReleaseDate, &song.Text, &song.Link)
+	song, err := scanSong(stmt.QueryRowContext(ctx, id, includeDeleted))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.SongStorage{}, storage.ErrSongNotFound
@@ -55,20 +89,20 @@ func (s *Storage) GetSongByID(id int64) (models.SongStorage, error) {
 	return song, nil
 }
 
-func (s *Storage) GetSongByName(songName string) (models.SongStorage, error) {
+func (s *Storage) GetSongByName(ctx context.Context, songName string) (models.SongStorage, error) {
 	const op = "storage.postgres.GetSongByName"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	stmt, err := s.db.Prepare("SELECT * FROM songs WHERE name = $1")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT "+songColumns+" FROM songs WHERE name = $1 AND deleted_at IS NULL")
 	if err != nil {
 		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(songName)
-
-	var song models.SongStorage
-
-	err = row.Scan(&song.ID, &song.Name, &song.GroupID, &song.ReleaseDate, &song.Text, &song.Link)
+	song, err := scanSong(stmt.QueryRowContext(ctx, songName))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.SongStorage{}, storage.ErrSongNotFound
@@ -80,44 +114,91 @@ func (s *Storage) GetSongByName(songName string) (models.SongStorage, error) {
 	return song, nil
 }
 
-func (s *Storage) UpdateSong(id int64, song models.SongStorage) (models.SongStorage, error) {
+// UpdateSong overwrites the song and records the revision in the same
+// transaction. When the text changes, the lyrics sections are rebuilt from it
+// in that transaction too. A non-zero song.Version makes the update
+// conditional on the song not having been modified since.
+func (s *Storage) UpdateSong(ctx context.Context, id int64, song models.SongStorage, change models.SongChange) (models.SongStorage, error) {
 	const op = "storage.postgres.UpdateSong"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	stmt, err := s.db.Prepare(
-		"UPDATE songs SET name = $1, group_id = $2, release_date = $3, text = $4, link = $5 WHERE id = $6",
-	)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
-
-	res, err := stmt.Exec(song.Name, song.GroupID, song.ReleaseDate, song.Text, song.Link, id)
+	defer tx.Rollback()
+
+	var text string
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(text, '') FROM songs
+		WHERE id = $1 AND deleted_at IS NULL AND ($2::bigint = 0 OR version = $2)
+		FOR UPDATE`,
+		id, song.Version,
+	).Scan(&text)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SongStorage{}, s.songMissingOrModified(ctx, id)
+		}
+
 		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	err = tx.QueryRowContext(ctx,
+		`UPDATE songs
+		SET name = $1, group_id = $2, release_date = $3, text = $4, link = $5, version = version + 1
+		WHERE id = $6
+		RETURNING version`,
+		song.Name, song.GroupID, nullDate(song.ReleaseDate), song.Text, song.Link, id,
+	).Scan(&song.Version)
 	if err != nil {
 		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return models.SongStorage{}, storage.ErrSongNotFound
+	if text != song.Text {
+		if err := replaceSections(ctx, tx, id, lyrics.FromText(song.Text)); err != nil {
+			return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, id, change); err != nil {
+		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.SongStorage{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return song, nil
 }
 
-func (s *Storage) DeleteSong(id int64) error {
+// DeleteSong moves the song to the trash after recording its last state as a
+// delete revision. A non-zero version makes the delete conditional on the
+// song not having been modified since.
+func (s *Storage) DeleteSong(ctx context.Context, id int64, version int64, change models.SongChange) error {
 	const op = "storage.postgres.DeleteSong"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	stmt, err := s.db.Prepare("DELETE FROM songs WHERE id = $1")
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
+
+	if err := recordRevision(ctx, tx, id, change); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	res, err := stmt.Exec(id)
+	res, err := tx.ExecContext(ctx,
+		`UPDATE songs SET deleted_at = now(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2::bigint = 0 OR version = $2)`,
+		id, version,
+	)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -128,51 +209,122 @@ func (s *Storage) DeleteSong(id int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return storage.ErrSongNotFound
+		return s.songMissingOrModified(ctx, id)
 	}
 
-	fmt.Println("No error")
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	return nil
 }
 
-func (s *Storage) GetAllSongs(filter models.SongFilter, groupID int64, offset int, limit int) ([]models.SongStorage, error) {
-	const op = "storage.postgres.GetAllSongs"
+// songMissingOrModified tells why a conditional write to the song matched
+// no rows.
+func (s *Storage) songMissingOrModified(ctx context.Context, id int64) error {
+	const op = "storage.postgres.songMissingOrModified"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	baseQuery := "SELECT * FROM songs WHERE 1=1"
-	var conditions []string
-	var args []interface{}
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	if groupID != 0 {
-		conditions = append(conditions, "group_id = $"+fmt.Sprint(len(args)+1))
-		args = append(args, groupID)
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	if filter.Name != "" {
-		conditions = append(conditions, "name = $"+fmt.Sprint(len(args)+1))
-		args = append(args, filter.Name)
+
+	if exists {
+		return storage.ErrSongModified
 	}
-	if !filter.ReleaseDate.IsZero() {
-		conditions = append(conditions, "release_date = $"+fmt.Sprint(len(args)+1))
-		args = append(args, filter.ReleaseDate.GoString())
+
+	return storage.ErrSongNotFound
+}
+
+const songColumns = "id, name, group_id, release_date, text, link, enrichment_status, version, deleted_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSong reads a row selected with songColumns. release_date stays NULL
+// until a pending song is enriched, so it is scanned through sql.NullTime.
+func scanSong(row rowScanner) (models.SongStorage, error) {
+	var song models.SongStorage
+	var releaseDate, deletedAt sql.NullTime
+
+	err := row.Scan(
+		&song.ID, &song.Name, &song.GroupID, &releaseDate, &song.Text, &song.Link, &song.EnrichmentStatus, &song.Version,
+		&deletedAt,
+	)
+	if err != nil {
+		return models.SongStorage{}, err
 	}
-	if filter.Text != "" {
-		conditions = append(conditions, "text = $"+fmt.Sprint(len(args)+1))
-		args = append(args, filter.Text)
+
+	song.ReleaseDate = releaseDate.Time
+	if deletedAt.Valid {
+		song.DeletedAt = &deletedAt.Time
 	}
-	if filter.Link != "" {
-		conditions = append(conditions, "link = $"+fmt.Sprint(len(args)+1))
-		args = append(args, filter.Link)
+
+	return song, nil
+}
+
+func nullDate(date time.Time) sql.NullTime {
+	return sql.NullTime{Time: date, Valid: !date.IsZero()}
+}
+
+type sortColumn struct {
+	expr string
+	cast string
+}
+
+var songSortColumns = map[string]sortColumn{
+	"id":          {expr: "s.id", cast: "::bigint"},
+	"name":        {expr: "s.name", cast: "::text"},
+	"releaseDate": {expr: "COALESCE(s.release_date, '0001-01-01'::date)", cast: "::date"},
+	"group":       {expr: "COALESCE(g.name, '')", cast: "::text"},
+}
+
+func (s *Storage) GetAllSongs(
+	ctx context.Context,
+	filter models.SongFilter,
+	after *models.SongCursor,
+	limit int,
+) ([]models.SongStorage, error) {
+	const op = "storage.postgres.GetAllSongs"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	baseQuery := `SELECT s.id, s.name, s.group_id, s.release_date, s.text, s.link, s.enrichment_status, s.version, s.deleted_at
+		FROM songs s
+		LEFT JOIN groups g ON g.id = s.group_id
+		WHERE 1=1`
+	conditions, args := songFilterConditions(filter)
+
+	orderBy, err := songOrderBy(filter.Sort)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	args = append(args, offset, limit)
+	if after != nil {
+		condition, keysetArgs, err := songKeysetCondition(filter.Sort, after, len(args))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		conditions = append(conditions, condition)
+		args = append(args, keysetArgs...)
+	}
+
+	args = append(args, limit)
 
 	finalQuery := baseQuery
 	if len(conditions) > 0 {
 		finalQuery += " AND " + strings.Join(conditions, " AND ")
 	}
-	finalQuery += " OFFSET $" + fmt.Sprint(len(args)-1) + " LIMIT $" + fmt.Sprint(len(args))
+	finalQuery += " ORDER BY " + orderBy + " LIMIT $" + fmt.Sprint(len(args))
 
-	rows, err := s.db.Query(finalQuery, args...)
+	rows, err := s.db.QueryContext(ctx, finalQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -180,12 +332,165 @@ func (s *Storage) GetAllSongs(filter models.SongFilter, groupID int64, offset in
 
 	var songs []models.SongStorage
 	for rows.Next() {
-		var song models.SongStorage
-		if err := rows.Scan(&song.ID, &song.Name, &song.GroupID, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
+		song, err := scanSong(rows)
+		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 		songs = append(songs, song)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return songs, nil
 }
+
+func (s *Storage) CountSongs(ctx context.Context, filter models.SongFilter) (int64, error) {
+	const op = "storage.postgres.CountSongs"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT COUNT(*)
+		FROM songs s
+		LEFT JOIN groups g ON g.id = s.group_id
+		WHERE 1=1`
+	conditions, args := songFilterConditions(filter)
+
+	if len(conditions) > 0 {
+		query += " AND " + strings.Join(conditions, " AND ")
+	}
+
+	var count int64
+
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func songFilterConditions(filter models.SongFilter) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "s.deleted_at IS NULL")
+	}
+	if filter.Group != "" {
+		conditions = append(conditions, "g.name ILIKE $"+fmt.Sprint(len(args)+1))
+		args = append(args, "%"+escapeLike(filter.Group)+"%")
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "s.name ILIKE $"+fmt.Sprint(len(args)+1))
+		args = append(args, "%"+escapeLike(filter.Name)+"%")
+	}
+	if !filter.ReleaseDate.IsZero() {
+		conditions = append(conditions, "s.release_date = $"+fmt.Sprint(len(args)+1))
+		args = append(args, filter.ReleaseDate)
+	}
+	if !filter.ReleaseDateFrom.IsZero() {
+		conditions = append(conditions, "s.release_date >= $"+fmt.Sprint(len(args)+1))
+		args = append(args, filter.ReleaseDateFrom)
+	}
+	if !filter.ReleaseDateTo.IsZero() {
+		conditions = append(conditions, "s.release_date <= $"+fmt.Sprint(len(args)+1))
+		args = append(args, filter.ReleaseDateTo)
+	}
+	if filter.TextContains != "" {
+		conditions = append(conditions, "s.text ILIKE $"+fmt.Sprint(len(args)+1))
+		args = append(args, "%"+escapeLike(filter.TextContains)+"%")
+	}
+	if filter.HasText != nil {
+		if *filter.HasText {
+			conditions = append(conditions, "COALESCE(s.text, '') <> ''")
+		} else {
+			conditions = append(conditions, "COALESCE(s.text, '') = ''")
+		}
+	}
+	if filter.HasLink != nil {
+		if *filter.HasLink {
+			conditions = append(conditions, "COALESCE(s.link, '') <> ''")
+		} else {
+			conditions = append(conditions, "COALESCE(s.link, '') = ''")
+		}
+	}
+	if filter.Text != "" {
+		conditions = append(conditions, "s.text = $"+fmt.Sprint(len(args)+1))
+		args = append(args, filter.Text)
+	}
+	if filter.Link != "" {
+		conditions = append(conditions, "s.link = $"+fmt.Sprint(len(args)+1))
+		args = append(args, filter.Link)
+	}
+
+	return conditions, args
+}
+
+func songOrderBy(keys []models.SortKey) (string, error) {
+	var order []string
+
+	for _, key := range keys {
+		column, ok := songSortColumns[key.Field]
+		if !ok {
+			return "", fmt.Errorf("unknown sort field %q", key.Field)
+		}
+
+		if key.Desc {
+			order = append(order, column.expr+" DESC")
+		} else {
+			order = append(order, column.expr+" ASC")
+		}
+	}
+
+	return strings.Join(order, ", "), nil
+}
+
+// songKeysetCondition builds the "row goes after the cursor" predicate for the
+// given sort keys. Keys may have mixed directions, so instead of a row
+// comparison it expands to (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+func songKeysetCondition(keys []models.SortKey, after *models.SongCursor, argOffset int) (string, []interface{}, error) {
+	var args []interface{}
+	placeholders := make([]string, len(keys))
+	values := after.Values
+
+	for i, key := range keys {
+		column := songSortColumns[key.Field]
+
+		if key.Field == "id" {
+			args = append(args, after.ID)
+		} else {
+			if len(values) == 0 {
+				return "", nil, fmt.Errorf("cursor does not match sort keys")
+			}
+			args = append(args, values[0])
+			values = values[1:]
+		}
+		placeholders[i] = "$" + fmt.Sprint(argOffset+len(args)) + column.cast
+	}
+
+	if len(values) != 0 {
+		return "", nil, fmt.Errorf("cursor does not match sort keys")
+	}
+
+	var alternatives []string
+	for i, key := range keys {
+		var parts []string
+
+		for j := 0; j < i; j++ {
+			parts = append(parts, songSortColumns[keys[j].Field].expr+" = "+placeholders[j])
+		}
+
+		operator := " > "
+		if key.Desc {
+			operator = " < "
+		}
+		parts = append(parts, songSortColumns[key.Field].expr+operator+placeholders[i])
+
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
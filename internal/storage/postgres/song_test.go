@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"reflect"
+	"testing"
+)
+
+func TestSongKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      []models.SortKey
+		after     models.SongCursor
+		argOffset int
+		want      string
+		wantArgs  []interface{}
+	}{
+		{
+			name:     "id only",
+			keys:     []models.SortKey{{Field: "id"}},
+			after:    models.SongCursor{ID: 7},
+			want:     "((s.id > $1::bigint))",
+			wantArgs: []interface{}{int64(7)},
+		},
+		{
+			name:      "ascending with tie breaker after filter arguments",
+			keys:      []models.SortKey{{Field: "name"}, {Field: "id"}},
+			after:     models.SongCursor{Values: []string{"Uprising"}, ID: 7},
+			argOffset: 2,
+			want:      "((s.name > $3::text) OR (s.name = $3::text AND s.id > $4::bigint))",
+			wantArgs:  []interface{}{"Uprising", int64(7)},
+		},
+		{
+			name:  "mixed directions and the NULL release date",
+			keys:  []models.SortKey{{Field: "releaseDate", Desc: true}, {Field: "group"}, {Field: "id", Desc: true}},
+			after: models.SongCursor{Values: []string{"0001-01-01", "Muse"}, ID: 7},
+			want: "((COALESCE(s.release_date, '0001-01-01'::date) < $1::date)" +
+				" OR (COALESCE(s.release_date, '0001-01-01'::date) = $1::date AND COALESCE(g.name, '') > $2::text)" +
+				" OR (COALESCE(s.release_date, '0001-01-01'::date) = $1::date AND COALESCE(g.name, '') = $2::text AND s.id < $3::bigint))",
+			wantArgs: []interface{}{"0001-01-01", "Muse", int64(7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := songKeysetCondition(tt.keys, &tt.after, tt.argOffset)
+			if err != nil {
+				t.Fatalf("songKeysetCondition() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("songKeysetCondition() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("songKeysetCondition() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSongKeysetConditionMismatch(t *testing.T) {
+	keys := []models.SortKey{{Field: "name"}, {Field: "id"}}
+
+	for _, values := range [][]string{nil, {"a", "b"}} {
+		if _, _, err := songKeysetCondition(keys, &models.SongCursor{Values: values, ID: 7}, 0); err == nil {
+			t.Errorf("songKeysetCondition() with values %q: error = nil, want an error", values)
+		}
+	}
+}
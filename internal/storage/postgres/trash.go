@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"effectivemobiletesttask/internal/domain/models"
+	"effectivemobiletesttask/internal/storage"
+	"effectivemobiletesttask/internal/utils/metrics"
+	"effectivemobiletesttask/internal/utils/tracing"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UndeleteSong takes the song out of the trash together with its group when
+// the group is in the trash too, and records a restore revision.
+func (s *Storage) UndeleteSong(ctx context.Context, id int64, change models.SongChange) error {
+	const op = "storage.postgres.UndeleteSong"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var groupID int64
+	var deletedAt sql.NullTime
+
+	err = tx.QueryRowContext(ctx, "SELECT group_id, deleted_at FROM songs WHERE id = $1 FOR UPDATE", id).Scan(&groupID, &deletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !deletedAt.Valid {
+		return fmt.Errorf("%s: %w", op, storage.ErrNotInTrash)
+	}
+
+	if _, err := undeleteGroup(ctx, tx, groupID); err != nil && !errors.Is(err, storage.ErrNotInTrash) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE songs SET deleted_at = NULL, version = version + 1 WHERE id = $1", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, id, change); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UndeleteGroup takes the group out of the trash together with the songs
+// that were deleted with it. Each of them gets a restore revision.
+func (s *Storage) UndeleteGroup(ctx context.Context, id int64, change models.SongChange) error {
+	const op = "storage.postgres.UndeleteGroup"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	deletedAt, err := undeleteGroup(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`UPDATE songs SET deleted_at = NULL, version = version + 1
+		WHERE group_id = $1 AND deleted_at = $2
+		RETURNING id`,
+		id, deletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var songIDs []int64
+	for rows.Next() {
+		var songID int64
+		if err := rows.Scan(&songID); err != nil {
+			rows.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		songIDs = append(songIDs, songID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, songID := range songIDs {
+		if err := recordRevision(ctx, tx, songID, change); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// undeleteGroup takes the group out of the trash and returns the time it was
+// deleted at. It fails when a live group has taken the name in the meantime.
+func undeleteGroup(ctx context.Context, tx *sql.Tx, id int64) (time.Time, error) {
+	var name string
+	var deletedAt sql.NullTime
+
+	err := tx.QueryRowContext(ctx, "SELECT name, deleted_at FROM groups WHERE id = $1 FOR UPDATE", id).Scan(&name, &deletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, storage.ErrGroupNotFound
+		}
+		return time.Time{}, err
+	}
+
+	if !deletedAt.Valid {
+		return time.Time{}, storage.ErrNotInTrash
+	}
+
+	var nameTaken bool
+
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM groups WHERE name = $1 AND deleted_at IS NULL)", name).Scan(&nameTaken)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if nameTaken {
+		return time.Time{}, storage.ErrGroupExists
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE groups SET deleted_at = NULL WHERE id = $1", id); err != nil {
+		return time.Time{}, err
+	}
+
+	return deletedAt.Time, nil
+}
+
+// ListTrash returns a page of deleted songs and groups, most recently deleted
+// first.
+func (s *Storage) ListTrash(ctx context.Context, offset int, limit int) ([]models.TrashItem, error) {
+	const op = "storage.postgres.ListTrash"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT 'song', s.id, s.name, COALESCE(g.name, ''), s.deleted_at
+		FROM songs s
+		LEFT JOIN groups g ON g.id = s.group_id
+		WHERE s.deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'group', id, name, '', deleted_at
+		FROM groups
+		WHERE deleted_at IS NOT NULL
+		ORDER BY 5 DESC, 1, 2
+		OFFSET $1 LIMIT $2`,
+		offset, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		if err := rows.Scan(&item.Type, &item.ID, &item.Name, &item.Group, &item.DeletedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+// PurgeTrash removes songs and groups deleted before the given time for
+// good. A group stays in the trash as long as it still has songs. Revisions
+// are kept, so a purged song can still be restored from its history.
+func (s *Storage) PurgeTrash(ctx context.Context, before time.Time) (models.PurgeResult, error) {
+	const op = "storage.postgres.PurgeTrash"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var result models.PurgeResult
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM songs WHERE deleted_at < $1", before)
+	if err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.Songs, err = res.RowsAffected(); err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	res, err = tx.ExecContext(ctx,
+		`DELETE FROM groups g
+		WHERE g.deleted_at < $1 AND NOT EXISTS (SELECT 1 FROM songs s WHERE s.group_id = g.id)`,
+		before,
+	)
+	if err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.Groups, err = res.RowsAffected(); err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%s: unable to fetch affected rows: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurgeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
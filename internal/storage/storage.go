@@ -1,8 +1,21 @@
 package storage
 
-import "errors"
+import "effectivemobiletesttask/internal/domain/errs"
 
 var (
-	ErrSongNotFound  = errors.New("song was not found")
-	ErrGroupNotFound = errors.New("group was not found")
+	ErrSongNotFound  = errs.New(errs.KindNotFound, "song_not_found", "song was not found")
+	ErrSongModified  = errs.New(errs.KindPrecondition, "song_modified", "song was modified by another request")
+	ErrGroupNotFound = errs.New(errs.KindNotFound, "group_not_found", "group was not found")
+	ErrGroupExists   = errs.New(errs.KindConflict, "group_exists", "group already exists")
+	ErrGroupHasSongs = errs.New(errs.KindConflict, "group_has_songs", "group has songs")
+
+	ErrRevisionNotFound = errs.New(errs.KindNotFound, "revision_not_found", "song revision was not found")
+	ErrNotInTrash       = errs.New(errs.KindNotFound, "not_in_trash", "item is not in the trash")
+
+	ErrCacheEntryNotFound = errs.New(errs.KindNotFound, "cache_entry_not_found", "cache entry was not found")
+
+	ErrEnrichmentLeaseLost = errs.New(errs.KindConflict, "enrichment_lease_lost", "enrichment job lease expired")
+
+	ErrAPIKeyNotFound = errs.New(errs.KindNotFound, "api_key_not_found", "API key was not found")
+	ErrRoleNotFound   = errs.New(errs.KindValidation, "unknown_role", "role does not exist")
 )
@@ -0,0 +1,58 @@
+// Package diff compares texts line by line.
+package diff
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"strings"
+)
+
+// Lines returns the changes turning from into to, computed from the longest
+// common subsequence of their lines. Unchanged lines are included so the
+// result can be rendered as a whole.
+func Lines(from string, to string) []models.LineChange {
+	a, b := split(from), split(to)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changes := make([]models.LineChange, 0, max(len(a), len(b)))
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			changes = append(changes, models.LineChange{Op: models.LineEqual, Text: a[i], FromLine: i + 1, ToLine: j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			changes = append(changes, models.LineChange{Op: models.LineDelete, Text: a[i], FromLine: i + 1})
+			i++
+		default:
+			changes = append(changes, models.LineChange{Op: models.LineInsert, Text: b[j], ToLine: j + 1})
+			j++
+		}
+	}
+
+	return changes
+}
+
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
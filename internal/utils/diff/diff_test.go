@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"reflect"
+	"testing"
+)
+
+func equal(text string, from int, to int) models.LineChange {
+	return models.LineChange{Op: models.LineEqual, Text: text, FromLine: from, ToLine: to}
+}
+
+func insert(text string, to int) models.LineChange {
+	return models.LineChange{Op: models.LineInsert, Text: text, ToLine: to}
+}
+
+func remove(text string, from int) models.LineChange {
+	return models.LineChange{Op: models.LineDelete, Text: text, FromLine: from}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []models.LineChange
+	}{
+		{
+			name: "both empty",
+			want: []models.LineChange{},
+		},
+		{
+			name: "from empty",
+			to:   "a\nb",
+			want: []models.LineChange{insert("a", 1), insert("b", 2)},
+		},
+		{
+			name: "to empty",
+			from: "a\nb",
+			want: []models.LineChange{remove("a", 1), remove("b", 2)},
+		},
+		{
+			name: "unchanged",
+			from: "a\nb",
+			to:   "a\nb",
+			want: []models.LineChange{equal("a", 1, 1), equal("b", 2, 2)},
+		},
+		{
+			name: "insert",
+			from: "a\nc",
+			to:   "a\nb\nc",
+			want: []models.LineChange{equal("a", 1, 1), insert("b", 2), equal("c", 2, 3)},
+		},
+		{
+			name: "delete",
+			from: "a\nb\nc",
+			to:   "a\nc",
+			want: []models.LineChange{equal("a", 1, 1), remove("b", 2), equal("c", 3, 2)},
+		},
+		{
+			name: "replace",
+			from: "a\nb\nc",
+			to:   "a\nx\nc",
+			want: []models.LineChange{equal("a", 1, 1), remove("b", 2), insert("x", 2), equal("c", 3, 3)},
+		},
+		{
+			name: "moved line",
+			from: "a\nb\nc",
+			to:   "b\nc\na",
+			want: []models.LineChange{remove("a", 1), equal("b", 2, 1), equal("c", 3, 2), insert("a", 3)},
+		},
+		{
+			name: "CRLF matches LF",
+			from: "a\r\nb",
+			to:   "a\nb",
+			want: []models.LineChange{equal("a", 1, 1), equal("b", 2, 2)},
+		},
+		{
+			name: "blank lines count",
+			from: "a\n\nb",
+			to:   "a\nb",
+			want: []models.LineChange{equal("a", 1, 1), remove("", 2), equal("b", 3, 2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+package jsonpatch
+
+import (
+	"bytes"
+	"effectivemobiletesttask/internal/domain/errs"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch = errs.New(errs.KindValidation, "invalid_patch", "Patch document is not valid")
+	ErrTestFailed   = errs.New(errs.KindConflict, "patch_test_failed", "Patch test operation failed")
+)
+
+// MergePatch applies an RFC 7396 merge patch to doc: members of patch
+// replace members of doc, null removes them and objects are merged
+// recursively.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target, changes any
+
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("jsonpatch.MergePatch: %w", err)
+	}
+
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, ErrInvalidPatch.Withf("%s", err)
+	}
+
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = merge(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies an RFC 6902 patch to doc. Operations are applied in order
+// and the patch fails as a whole when any of them fails.
+func Apply(doc []byte, patch []byte) ([]byte, error) {
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("jsonpatch.Apply: %w", err)
+	}
+
+	var operations []operation
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&operations); err != nil {
+		return nil, ErrInvalidPatch.Withf("%s", err)
+	}
+
+	for i, op := range operations {
+		var err error
+
+		root, err = op.apply(root)
+		if err != nil {
+			if e, ok := errs.As(err); ok {
+				return nil, e.Withf("operation %d (%s): %s", i, op.Op, e.Detail)
+			}
+			return nil, err
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func (op operation) apply(root any) (any, error) {
+	if op.Path == nil {
+		return nil, ErrInvalidPatch.Withf("missing path")
+	}
+
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, ErrInvalidPatch.Withf("missing value")
+		}
+
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, ErrInvalidPatch.Withf("%s", err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(root, path, value)
+		case "replace":
+			return replace(root, path, value)
+		default:
+			current, err := get(root, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed.Withf("value at %q differs", *op.Path)
+			}
+			return root, nil
+		}
+	case "remove":
+		root, _, err := remove(root, path)
+		return root, err
+	case "move", "copy":
+		if op.From == nil {
+			return nil, ErrInvalidPatch.Withf("missing from")
+		}
+
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			value, err := get(root, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(root, path, deepCopy(value))
+		}
+
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, ErrInvalidPatch.Withf("cannot move %q into itself", *op.From)
+		}
+
+		root, value, err := remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	default:
+		return nil, ErrInvalidPatch.Withf("unknown op %q", op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, ErrInvalidPatch.Withf("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, token := range path {
+		var err error
+
+		node, err = child(node, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+func add(root any, path []string, value any) (any, error) {
+	return update(root, path, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[key] = value
+			return container, nil
+		case []any:
+			if key == "-" {
+				return append(container, value), nil
+			}
+
+			i, err := index(container, key, len(container))
+			if err != nil {
+				return nil, err
+			}
+
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		default:
+			return nil, ErrInvalidPatch.Withf("cannot add %q to a scalar", key)
+		}
+	}, value)
+}
+
+func replace(root any, path []string, value any) (any, error) {
+	if _, err := get(root, path); err != nil {
+		return nil, err
+	}
+
+	return update(root, path, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[key] = value
+			return container, nil
+		case []any:
+			i, _ := index(container, key, len(container)-1)
+			container[i] = value
+			return container, nil
+		default:
+			return nil, ErrInvalidPatch.Withf("cannot replace %q in a scalar", key)
+		}
+	}, value)
+}
+
+func remove(root any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, ErrInvalidPatch.Withf("cannot remove the whole document")
+	}
+
+	removed, err := get(root, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root, err = update(root, path, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			delete(container, key)
+			return container, nil
+		case []any:
+			i, _ := index(container, key, len(container)-1)
+			return append(container[:i], container[i+1:]...), nil
+		default:
+			return nil, ErrInvalidPatch.Withf("cannot remove %q from a scalar", key)
+		}
+	}, nil)
+
+	return root, removed, err
+}
+
+// update walks to the parent of the last token of path and replaces it with
+// the result of change. A whole-document path replaces root with whole.
+func update(node any, path []string, change func(parent any, key string) (any, error), whole any) (any, error) {
+	if len(path) == 0 {
+		return whole, nil
+	}
+
+	if len(path) == 1 {
+		return change(node, path[0])
+	}
+
+	next, err := child(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	next, err = update(next, path[1:], change, whole)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := node.(type) {
+	case map[string]any:
+		container[path[0]] = next
+	case []any:
+		i, _ := index(container, path[0], len(container)-1)
+		container[i] = next
+	}
+
+	return node, nil
+}
+
+func child(node any, token string) (any, error) {
+	switch container := node.(type) {
+	case map[string]any:
+		value, ok := container[token]
+		if !ok {
+			return nil, ErrInvalidPatch.Withf("member %q does not exist", token)
+		}
+		return value, nil
+	case []any:
+		i, err := index(container, token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		return container[i], nil
+	default:
+		return nil, ErrInvalidPatch.Withf("cannot look up %q in a scalar", token)
+	}
+}
+
+func index(array []any, token string, last int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > last || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, ErrInvalidPatch.Withf("index %q is out of bounds of an array of %d elements", token, len(array))
+	}
+
+	return i, nil
+}
+
+func deepCopy(value any) any {
+	data, _ := json.Marshal(value)
+
+	var copied any
+	_ = json.Unmarshal(data, &copied)
+
+	return copied
+}
@@ -0,0 +1,322 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestApply covers the examples of RFC 6902, Appendix A, and the pointer
+// escaping of RFC 6901.
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "A.1 adding an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name:  "A.2 adding an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name:  "A.3 removing an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			name:  "A.4 removing an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:  "A.5 replacing a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name:  "A.6 moving a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name:  "A.7 moving an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name: "A.8 testing a value: success",
+			doc:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[
+				{"op": "test", "path": "/baz", "value": "qux"},
+				{"op": "test", "path": "/foo/1", "value": 2}
+			]`,
+			want: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:    "A.9 testing a value: error",
+			doc:     `{"baz": "qux"}`,
+			patch:   `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.10 adding a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name:    "A.11 unrecognized elements are rejected",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "A.12 adding to a nonexistent target",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "A.14 escape ordering",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10}]`,
+			want:  `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:    "A.15 comparing strings and numbers",
+			doc:     `{"/": 9, "~1": 10}`,
+			patch:   `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.16 adding an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		{
+			name:  "escaped slash in a pointer",
+			doc:   `{"a/b": 1}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 2}]`,
+			want:  `{"a/b": 2}`,
+		},
+		{
+			name:  "escaped tilde in a pointer",
+			doc:   `{"m~n": 1}`,
+			patch: `[{"op": "remove", "path": "/m~0n"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "empty member name",
+			doc:   `{"": 0}`,
+			patch: `[{"op": "test", "path": "/", "value": 0}]`,
+			want:  `{"": 0}`,
+		},
+		{
+			name:    "pointer without a leading slash",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "remove", "path": "foo"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "adding at the end of an array by index",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "baz"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:    "adding past the end of an array",
+			doc:     `{"foo": ["bar"]}`,
+			patch:   `[{"op": "add", "path": "/foo/2", "value": "baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "index with a leading zero",
+			doc:     `{"foo": ["bar", "baz"]}`,
+			patch:   `[{"op": "remove", "path": "/foo/01"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "dash index outside of add",
+			doc:     `{"foo": ["bar"]}`,
+			patch:   `[{"op": "replace", "path": "/foo/-", "value": "baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "replacing the whole document",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "", "value": [1, 2]}]`,
+			want:  `[1, 2]`,
+		},
+		{
+			name:    "replacing a missing member",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "replace", "path": "/baz", "value": "qux"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "removing a missing member",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "remove", "path": "/baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "copying a value",
+			doc:   `{"foo": {"bar": [1]}}`,
+			patch: `[{"op": "copy", "from": "/foo/bar", "path": "/baz"}, {"op": "add", "path": "/baz/-", "value": 2}]`,
+			want:  `{"foo": {"bar": [1]}, "baz": [1, 2]}`,
+		},
+		{
+			name:    "copying from a missing member",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "copy", "from": "/baz", "path": "/qux"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "moving a value into itself",
+			doc:     `{"foo": {"bar": 1}}`,
+			patch:   `[{"op": "move", "from": "/foo", "path": "/foo/baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "moving a value to the same place",
+			doc:   `{"foo": {"bar": 1}}`,
+			patch: `[{"op": "move", "from": "/foo", "path": "/foo"}]`,
+			want:  `{"foo": {"bar": 1}}`,
+		},
+		{
+			name:  "testing an object regardless of member order",
+			doc:   `{"foo": {"a": 1, "b": [true, null]}}`,
+			patch: `[{"op": "test", "path": "/foo", "value": {"b": [true, null], "a": 1}}]`,
+			want:  `{"foo": {"a": 1, "b": [true, null]}}`,
+		},
+		{
+			name: "a failing operation discards the whole patch",
+			doc:  `{"foo": "bar"}`,
+			patch: `[
+				{"op": "add", "path": "/baz", "value": "qux"},
+				{"op": "test", "path": "/foo", "value": "baz"}
+			]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:    "missing path",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "remove"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "missing value",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "add", "path": "/baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "missing from",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "move", "path": "/baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "increment", "path": "/foo"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "patch is not an array",
+			doc:     `{"foo": "bar"}`,
+			patch:   `{"op": "remove", "path": "/foo"}`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+// TestMergePatch covers the examples of RFC 7396, Appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+
+	if _, err := MergePatch([]byte(`{}`), []byte(`{`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("MergePatch() with a malformed patch: error = %v, want %v", err, ErrInvalidPatch)
+	}
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("result %s is not JSON: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("expected %s is not JSON: %v", want, err)
+	}
+
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
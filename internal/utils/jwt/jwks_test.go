@@ -0,0 +1,79 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeJWKS(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadJWKS(t *testing.T) {
+	key := newRSAKey(t)
+	n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+
+	path := writeJWKS(t, `{"keys": [
+		{"kty": "RSA", "kid": "sig", "use": "sig", "n": "`+n+`", "e": "`+e+`"},
+		{"kty": "RSA", "kid": "any", "n": "`+n+`", "e": "`+e+`"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "`+n+`", "e": "`+e+`"},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": "AA", "y": "AA"}
+	]}`)
+
+	keys, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatalf("LoadJWKS() error = %v", err)
+	}
+
+	if len(keys) != 2 || keys["sig"] == nil || keys["any"] == nil {
+		t.Fatalf("LoadJWKS() kids = %v, want sig and any", keys)
+	}
+	if !keys["sig"].Equal(&key.PublicKey) {
+		t.Errorf("LoadJWKS() key differs from the generated one")
+	}
+
+	v := NewVerifier(Options{Keys: keys})
+	v.now = func() time.Time { return now }
+
+	signed := token(t, map[string]any{"alg": "RS256", "kid": "sig"}, claims(nil), rs256(key))
+	if _, err := v.Verify(signed); err != nil {
+		t.Errorf("Verify() with a loaded key: error = %v", err)
+	}
+}
+
+func TestLoadJWKSErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not JSON", `keys`},
+		{"no keys", `{"keys": []}`},
+		{"no signing keys", `{"keys": [{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"}]}`},
+		{"bad modulus", `{"keys": [{"kty": "RSA", "kid": "k1", "n": "!!", "e": "AQAB"}]}`},
+		{"bad exponent", `{"keys": [{"kty": "RSA", "kid": "k1", "n": "AQAB", "e": "!!"}]}`},
+		{"exponent too large", `{"keys": [{"kty": "RSA", "kid": "k1", "n": "AQAB", "e": "AQAAAAAA"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadJWKS(writeJWKS(t, tt.data)); err == nil {
+				t.Errorf("LoadJWKS() error = nil, want an error")
+			}
+		})
+	}
+
+	if _, err := LoadJWKS(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("LoadJWKS() of a missing file: error = nil, want an error")
+	}
+}
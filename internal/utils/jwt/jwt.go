@@ -0,0 +1,223 @@
+// Package jwt verifies compact JSON Web Tokens (RFC 7519) signed with HS256
+// or RS256.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed            = errors.New("token is malformed")
+	ErrUnsupportedAlgorithm = errors.New("signing algorithm is not supported")
+	ErrUnknownKey           = errors.New("signing key is unknown")
+	ErrSignature            = errors.New("signature is invalid")
+	ErrExpired              = errors.New("token is expired")
+	ErrNotYetValid          = errors.New("token is not valid yet")
+	ErrIssuer               = errors.New("issuer is not accepted")
+	ErrAudience             = errors.New("audience is not accepted")
+	ErrSubject              = errors.New("subject is missing")
+)
+
+// Claims are the registered claims the verifier checks, and the private
+// roles claim, which grants the subject roles in addition to stored ones.
+type Claims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  Audience    `json:"aud"`
+	ExpiresAt NumericDate `json:"exp"`
+	NotBefore NumericDate `json:"nbf"`
+	IssuedAt  NumericDate `json:"iat"`
+	Roles     []string    `json:"roles"`
+}
+
+// Audience is the aud claim, which may be a single string or a list.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+
+	return nil
+}
+
+// NumericDate is a time claim in seconds since the epoch. The zero value
+// means the claim is absent.
+type NumericDate struct {
+	time.Time
+}
+
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+
+	whole, frac := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(frac*1e9))
+
+	return nil
+}
+
+// Options configure a Verifier. HS256 tokens are accepted when Secret is set,
+// RS256 tokens when Keys holds the public key of their kid. An empty Issuer
+// or Audience is not checked.
+type Options struct {
+	Secret   []byte
+	Keys     map[string]*rsa.PublicKey
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+type Verifier struct {
+	opts Options
+	now  func() time.Time
+}
+
+func NewVerifier(opts Options) *Verifier {
+	return &Verifier{opts: opts, now: time.Now}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the signature and the time, issuer and audience claims of
+// token and returns its claims. Tokens without exp or sub are rejected.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformed
+	}
+
+	var head header
+	if err := decodeSegment(parts[0], &head); err != nil {
+		return Claims{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if err := v.verifySignature(head, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, err
+	}
+
+	if err := v.verifyClaims(claims); err != nil {
+		return Claims{}, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(head header, signed string, signature []byte) error {
+	switch head.Alg {
+	case "HS256":
+		if len(v.opts.Secret) == 0 {
+			return ErrUnsupportedAlgorithm
+		}
+
+		mac := hmac.New(sha256.New, v.opts.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrSignature
+		}
+	case "RS256":
+		key, err := v.rsaKey(head.Kid)
+		if err != nil {
+			return err
+		}
+
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrSignature
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, head.Alg)
+	}
+
+	return nil
+}
+
+// rsaKey picks the key of kid. A token without kid is accepted when there is
+// exactly one key.
+func (v *Verifier) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if kid == "" && len(v.opts.Keys) == 1 {
+		for _, key := range v.opts.Keys {
+			return key, nil
+		}
+	}
+
+	key, ok := v.opts.Keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func (v *Verifier) verifyClaims(claims Claims) error {
+	now := v.now()
+
+	if claims.ExpiresAt.IsZero() || !now.Before(claims.ExpiresAt.Add(v.opts.Leeway)) {
+		return ErrExpired
+	}
+
+	if !claims.NotBefore.IsZero() && now.Add(v.opts.Leeway).Before(claims.NotBefore.Time) {
+		return ErrNotYetValid
+	}
+
+	if v.opts.Issuer != "" && claims.Issuer != v.opts.Issuer {
+		return ErrIssuer
+	}
+
+	if v.opts.Audience != "" && !slices.Contains(claims.Audience, v.opts.Audience) {
+		return ErrAudience
+	}
+
+	if claims.Subject == "" {
+		return ErrSubject
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s", ErrMalformed, err)
+	}
+
+	return nil
+}
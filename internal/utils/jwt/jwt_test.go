@@ -0,0 +1,342 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+type signer func(t *testing.T, signed string) []byte
+
+func hs256(secret []byte) signer {
+	return func(t *testing.T, signed string) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(key *rsa.PrivateKey) signer {
+	return func(t *testing.T, signed string) []byte {
+		t.Helper()
+
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("SignPKCS1v15() error = %v", err)
+		}
+		return signature
+	}
+}
+
+func unsigned(*testing.T, string) []byte {
+	return nil
+}
+
+// token encodes the header and claims and signs them with sign.
+func token(t *testing.T, head map[string]any, claims map[string]any, sign signer) string {
+	t.Helper()
+
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(head) + "." + encode(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(t, signed))
+}
+
+// claims are valid at now, overridden by the given members. A nil value
+// removes the member.
+func claims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"sub": "ci-runner",
+		"iss": "issuer",
+		"aud": "song-lib",
+		"exp": now.Add(time.Hour).Unix(),
+		"nbf": now.Add(-time.Minute).Unix(),
+		"iat": now.Add(-time.Minute).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(c, name)
+			continue
+		}
+		c[name] = value
+	}
+	return c
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	return key
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+	key := newRSAKey(t)
+	otherKey := newRSAKey(t)
+
+	publicPEM, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	publicPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicPEM})
+
+	hsOnly := Options{Secret: secret, Issuer: "issuer", Audience: "song-lib", Leeway: 30 * time.Second}
+	rsOnly := Options{Keys: map[string]*rsa.PublicKey{"k1": &key.PublicKey}, Issuer: "issuer", Audience: "song-lib", Leeway: 30 * time.Second}
+	both := Options{
+		Secret:   secret,
+		Keys:     map[string]*rsa.PublicKey{"k1": &key.PublicKey, "k2": &otherKey.PublicKey},
+		Issuer:   "issuer",
+		Audience: "song-lib",
+		Leeway:   30 * time.Second,
+	}
+
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+	rs := map[string]any{"alg": "RS256", "typ": "JWT", "kid": "k1"}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "HS256",
+			opts:  hsOnly,
+			token: token(t, hs, claims(nil), hs256(secret)),
+		},
+		{
+			name:  "RS256",
+			opts:  rsOnly,
+			token: token(t, rs, claims(nil), rs256(key)),
+		},
+		{
+			name:  "RS256 without kid and a single key",
+			opts:  rsOnly,
+			token: token(t, map[string]any{"alg": "RS256"}, claims(nil), rs256(key)),
+		},
+		{
+			name:    "RS256 without kid and several keys",
+			opts:    both,
+			token:   token(t, map[string]any{"alg": "RS256"}, claims(nil), rs256(key)),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "unknown kid",
+			opts:    rsOnly,
+			token:   token(t, map[string]any{"alg": "RS256", "kid": "k9"}, claims(nil), rs256(key)),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "kid of another key",
+			opts:    both,
+			token:   token(t, map[string]any{"alg": "RS256", "kid": "k2"}, claims(nil), rs256(key)),
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "alg none",
+			opts:    both,
+			token:   token(t, map[string]any{"alg": "none"}, claims(nil), unsigned),
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "alg None",
+			opts:    both,
+			token:   token(t, map[string]any{"alg": "None"}, claims(nil), unsigned),
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "missing alg",
+			opts:    both,
+			token:   token(t, map[string]any{"typ": "JWT"}, claims(nil), hs256(secret)),
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "HS256 signed with the RSA public key",
+			opts:    rsOnly,
+			token:   token(t, map[string]any{"alg": "HS256", "kid": "k1"}, claims(nil), hs256(publicPEM)),
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "HS256 signed with the RSA public key when a secret is set",
+			opts:    both,
+			token:   token(t, map[string]any{"alg": "HS256", "kid": "k1"}, claims(nil), hs256(publicPEM)),
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "RS256 when only a secret is set",
+			opts:    hsOnly,
+			token:   token(t, rs, claims(nil), rs256(key)),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "HS256 with a wrong secret",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(nil), hs256([]byte("other"))),
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "tampered claims",
+			opts:    hsOnly,
+			token:   tamper(t, token(t, hs, claims(nil), hs256(secret)), claims(map[string]any{"sub": "admin"})),
+			wantErr: ErrSignature,
+		},
+		{
+			name:  "expired within the leeway",
+			opts:  hsOnly,
+			token: token(t, hs, claims(map[string]any{"exp": now.Add(-29 * time.Second).Unix()}), hs256(secret)),
+		},
+		{
+			name:    "expired at the end of the leeway",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}), hs256(secret)),
+			wantErr: ErrExpired,
+		},
+		{
+			name:    "expired beyond the leeway",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(map[string]any{"exp": now.Add(-time.Hour).Unix()}), hs256(secret)),
+			wantErr: ErrExpired,
+		},
+		{
+			name:  "fractional exp",
+			opts:  hsOnly,
+			token: token(t, hs, claims(map[string]any{"exp": float64(now.Unix()) + 0.5}), hs256(secret)),
+		},
+		{
+			name:    "missing exp",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(map[string]any{"exp": nil}), hs256(secret)),
+			wantErr: ErrExpired,
+		},
+		{
+			name:  "not before within the leeway",
+			opts:  hsOnly,
+			token: token(t, hs, claims(map[string]any{"nbf": now.Add(30 * time.Second).Unix()}), hs256(secret)),
+		},
+		{
+			name:    "not before beyond the leeway",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(map[string]any{"nbf": now.Add(31 * time.Second).Unix()}), hs256(secret)),
+			wantErr: ErrNotYetValid,
+		},
+		{
+			name:  "missing nbf",
+			opts:  hsOnly,
+			token: token(t, hs, claims(map[string]any{"nbf": nil}), hs256(secret)),
+		},
+		{
+			name:    "wrong issuer",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(map[string]any{"iss": "someone"}), hs256(secret)),
+			wantErr: ErrIssuer,
+		},
+		{
+			name:  "audience list",
+			opts:  hsOnly,
+			token: token(t, hs, claims(map[string]any{"aud": []string{"other", "song-lib"}}), hs256(secret)),
+		},
+		{
+			name:    "wrong audience",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(map[string]any{"aud": []string{"other"}}), hs256(secret)),
+			wantErr: ErrAudience,
+		},
+		{
+			name:    "missing subject",
+			opts:    hsOnly,
+			token:   token(t, hs, claims(map[string]any{"sub": nil}), hs256(secret)),
+			wantErr: ErrSubject,
+		},
+		{
+			name:    "two segments",
+			opts:    hsOnly,
+			token:   "eyJhbGciOiJIUzI1NiJ9.e30",
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "header is not base64url",
+			opts:    hsOnly,
+			token:   "!!.e30.",
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "claims are not JSON",
+			opts:    hsOnly,
+			token:   signedRaw(t, hs, "not json", hs256(secret)),
+			wantErr: ErrMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(tt.opts)
+			v.now = func() time.Time { return now }
+
+			got, err := v.Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if got.Subject != "ci-runner" {
+				t.Errorf("Verify() subject = %q, want %q", got.Subject, "ci-runner")
+			}
+		})
+	}
+}
+
+// tamper replaces the claims of a signed token and keeps its signature.
+func tamper(t *testing.T, signed string, claims map[string]any) string {
+	t.Helper()
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	parts := strings.Split(signed, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString(data)
+	return strings.Join(parts, ".")
+}
+
+// signedRaw signs a token whose claims segment is raw rather than JSON
+// encoded.
+func signedRaw(t *testing.T, head map[string]any, raw string, sign signer) string {
+	t.Helper()
+
+	data, err := json.Marshal(head)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString([]byte(raw))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(t, signed))
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"effectivemobiletesttask/internal/domain/principal"
+	"log/slog"
+)
+
+// ContextHandler adds the principal of the context to every record logged
+// with one of the *Context methods.
+type ContextHandler struct {
+	slog.Handler
+}
+
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if p, ok := principal.From(ctx); ok {
+		record.AddAttrs(slog.String("principal", p.Subject), slog.String("auth", p.Method))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewContextHandler(h.Handler.WithAttrs(attrs))
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return NewContextHandler(h.Handler.WithGroup(name))
+}
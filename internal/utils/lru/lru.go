@@ -0,0 +1,143 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Item is a cached value with the moment it expires.
+type Item[K comparable, V any] struct {
+	Key       K
+	Value     V
+	ExpiresAt time.Time
+}
+
+// Cache is a fixed-capacity least recently used cache. Every entry carries
+// its own TTL; expired entries are dropped when they are read.
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[K]*list.Element
+}
+
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	return &Cache[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	item := elem.Value.(*Item[K, V])
+	if !time.Now().Before(item.ExpiresAt) {
+		c.remove(elem)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return item.Value, true
+}
+
+// Peek returns the item without refreshing its recency.
+func (c *Cache[K, V]) Peek(key K) (Item[K, V], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return Item[K, V]{}, false
+	}
+
+	item := elem.Value.(*Item[K, V])
+	if !time.Now().Before(item.ExpiresAt) {
+		return Item[K, V]{}, false
+	}
+
+	return *item, true
+}
+
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 || ttl <= 0 {
+		return
+	}
+
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*Item[K, V])
+		item.Value = value
+		item.ExpiresAt = time.Now().Add(ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&Item[K, V]{Key: key, Value: value, ExpiresAt: time.Now().Add(ttl)})
+
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return false
+	}
+
+	c.remove(elem)
+
+	return true
+}
+
+// Purge drops every entry and returns how many there were.
+func (c *Cache[K, V]) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := c.order.Len()
+	c.order.Init()
+	c.items = make(map[K]*list.Element)
+
+	return count
+}
+
+// Items returns the live entries, most recently used first.
+func (c *Cache[K, V]) Items() []Item[K, V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	items := make([]Item[K, V], 0, c.order.Len())
+
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		item := elem.Value.(*Item[K, V])
+		if now.Before(item.ExpiresAt) {
+			items = append(items, *item)
+		}
+	}
+
+	return items
+}
+
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*Item[K, V]).Key)
+}
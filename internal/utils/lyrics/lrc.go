@@ -0,0 +1,130 @@
+package lyrics
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	lrcTimeTag = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	lrcMetaTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// ParseLRC parses LRC lyrics. Blank lines and lines with a time tag but no
+// text separate sections. The offset tag is applied to all timestamps, other
+// metadata tags are ignored.
+func ParseLRC(data string) ([]models.LyricsSection, error) {
+	var sections []models.LyricsSection
+	var current []models.LyricsLine
+	var offset int64
+
+	flush := func() {
+		if len(current) > 0 {
+			sections = append(sections, models.LyricsSection{Type: models.SectionVerse, Lines: current})
+			current = nil
+		}
+	}
+
+	for n, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+
+		if line == "" {
+			flush()
+			continue
+		}
+
+		match := lrcTimeTag.FindStringSubmatch(line)
+		if match == nil {
+			if meta := lrcMetaTag.FindStringSubmatch(line); meta != nil {
+				if strings.EqualFold(meta[1], "offset") {
+					value, err := strconv.ParseInt(strings.TrimSpace(meta[2]), 10, 64)
+					if err != nil {
+						return nil, ErrInvalidFormat.Withf("line %d: bad offset", n+1)
+					}
+					offset = value
+				}
+				continue
+			}
+
+			return nil, ErrInvalidFormat.Withf("line %d: missing time tag", n+1)
+		}
+
+		text := strings.TrimSpace(line[len(match[0]):])
+		if lrcTimeTag.MatchString(text) {
+			return nil, ErrInvalidFormat.Withf("line %d: repeated time tags are not supported", n+1)
+		}
+
+		if text == "" {
+			flush()
+			continue
+		}
+
+		startMs, err := lrcTimestamp(match)
+		if err != nil {
+			return nil, ErrInvalidFormat.Withf("line %d: %s", n+1, err)
+		}
+
+		// A positive offset makes lyrics appear sooner.
+		startMs = max(startMs-offset, 0)
+
+		current = append(current, models.LyricsLine{Text: text, StartMs: &startMs})
+	}
+	flush()
+
+	if len(sections) == 0 {
+		return nil, ErrInvalidFormat.Withf("no timed lines")
+	}
+
+	if err := validateMonotonic(sections); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+// FormatLRCText renders lyrics as LRC with a blank line between sections.
+func FormatLRCText(sections []models.LyricsSection) (string, error) {
+	if err := validateMonotonic(sections); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	for i, section := range sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		for _, line := range section.Lines {
+			ms := *line.StartMs
+			fmt.Fprintf(&b, "[%02d:%02d.%02d]%s\n", ms/60000, ms/1000%60, ms%1000/10, line.Text)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func lrcTimestamp(match []string) (int64, error) {
+	minutes, _ := strconv.ParseInt(match[1], 10, 64)
+	seconds, _ := strconv.ParseInt(match[2], 10, 64)
+	if seconds >= 60 {
+		return 0, fmt.Errorf("seconds out of range")
+	}
+
+	var fraction int64
+	if match[3] != "" {
+		fraction, _ = strconv.ParseInt(match[3], 10, 64)
+		// The fraction is hundredths in [mm:ss.xx] and milliseconds in [mm:ss.xxx].
+		switch len(match[3]) {
+		case 1:
+			fraction *= 100
+		case 2:
+			fraction *= 10
+		}
+	}
+
+	return minutes*60000 + seconds*1000 + fraction, nil
+}
@@ -0,0 +1,168 @@
+package lyrics
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type timedLine struct {
+	Text    string
+	StartMs int64
+}
+
+// timed flattens sections to their timed lines, one slice per section.
+func timed(t *testing.T, sections []models.LyricsSection) [][]timedLine {
+	t.Helper()
+
+	var got [][]timedLine
+	for _, section := range sections {
+		var lines []timedLine
+		for _, line := range section.Lines {
+			if line.StartMs == nil {
+				t.Fatalf("line %q has no start time", line.Text)
+			}
+			lines = append(lines, timedLine{Text: line.Text, StartMs: *line.StartMs})
+		}
+		got = append(got, lines)
+	}
+
+	return got
+}
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    [][]timedLine
+		wantErr error
+	}{
+		{
+			name: "hundredths",
+			data: "[00:12.34]Hello\n[01:02.50]World",
+			want: [][]timedLine{{{"Hello", 12340}, {"World", 62500}}},
+		},
+		{
+			name: "timestamp formats",
+			data: "[00:01]a\n[00:01.123]b\n[00:01.5]c\n[00:02:50]d\n[100:00.00]e",
+			want: [][]timedLine{{{"a", 1000}, {"b", 1123}, {"c", 1500}, {"d", 2500}, {"e", 6000000}}},
+		},
+		{
+			name: "blank lines and empty time tags separate sections",
+			data: "[00:01.00]a\n[00:02.00]b\n\n[00:03.00]c\n[00:04.00]\n[00:05.00]d",
+			want: [][]timedLine{{{"a", 1000}, {"b", 2000}}, {{"c", 3000}}, {{"d", 5000}}},
+		},
+		{
+			name: "metadata tags are skipped",
+			data: "[ar:Artist]\n[ti:Title]\n[00:01.00]a",
+			want: [][]timedLine{{{"a", 1000}}},
+		},
+		{
+			name: "offset moves lines sooner but not below zero",
+			data: "[offset:1500]\n[00:01.00]a\n[00:03.00]b",
+			want: [][]timedLine{{{"a", 0}, {"b", 1500}}},
+		},
+		{
+			name: "negative offset moves lines later",
+			data: "[offset:-250]\n[00:01.00]a",
+			want: [][]timedLine{{{"a", 1250}}},
+		},
+		{
+			name: "CRLF line endings and surrounding spaces",
+			data: "  [00:01.00] a \r\n\r\n[00:02.00]b\r\n",
+			want: [][]timedLine{{{"a", 1000}}, {{"b", 2000}}},
+		},
+		{
+			name: "equal timestamps are allowed",
+			data: "[00:01.00]a\n[00:01.00]b",
+			want: [][]timedLine{{{"a", 1000}, {"b", 1000}}},
+		},
+		{
+			name:    "out of order lines",
+			data:    "[00:02.00]a\n[00:01.00]b",
+			wantErr: ErrNonMonotonic,
+		},
+		{
+			name:    "out of order across sections",
+			data:    "[00:02.00]a\n\n[00:01.00]b",
+			wantErr: ErrNonMonotonic,
+		},
+		{
+			name:    "seconds out of range",
+			data:    "[00:60.00]a",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "line without a time tag",
+			data:    "[00:01.00]a\nplain text",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "repeated time tags",
+			data:    "[00:01.00][00:05.00]chorus",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "bad offset",
+			data:    "[offset:soon]\n[00:01.00]a",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "malformed time tag",
+			data:    "[0a:01.00]a",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "no timed lines",
+			data:    "[ar:Artist]\n\n[00:01.00]",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "empty input",
+			data:    "",
+			wantErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := ParseLRC(tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseLRC() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLRC() error = %v", err)
+			}
+
+			if got := timed(t, sections); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLRC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLRCText(t *testing.T) {
+	const data = "[00:01.00]a\n[01:02.34]b\n\n[10:00.00]c\n"
+
+	sections, err := ParseLRC(data)
+	if err != nil {
+		t.Fatalf("ParseLRC() error = %v", err)
+	}
+
+	got, err := FormatLRCText(sections)
+	if err != nil {
+		t.Fatalf("FormatLRCText() error = %v", err)
+	}
+	if got != data {
+		t.Errorf("FormatLRCText() = %q, want %q", got, data)
+	}
+
+	untimed := []models.LyricsSection{{Lines: []models.LyricsLine{{Text: "a"}}}}
+	if _, err := FormatLRCText(untimed); !errors.Is(err, ErrNotSynced) {
+		t.Errorf("FormatLRCText() of untimed lines: error = %v, want %v", err, ErrNotSynced)
+	}
+}
@@ -0,0 +1,64 @@
+package lyrics
+
+import (
+	"effectivemobiletesttask/internal/domain/errs"
+	"effectivemobiletesttask/internal/domain/models"
+)
+
+const (
+	FormatLRC = "lrc"
+	FormatSRT = "srt"
+)
+
+var (
+	ErrInvalidFormat = errs.New(errs.KindValidation, "lyrics_invalid_format", "invalid lyrics format")
+	ErrNonMonotonic  = errs.New(errs.KindValidation, "lyrics_not_monotonic", "timestamps are not monotonic")
+	ErrNotSynced     = errs.New(errs.KindConflict, "lyrics_not_synced", "lyrics have lines without timestamps")
+	ErrUnknownFormat = errs.New(errs.KindValidation, "lyrics_unknown_format", "unknown lyrics format")
+)
+
+// Parse reads synchronized lyrics in the given format.
+func Parse(format string, data string) ([]models.LyricsSection, error) {
+	switch format {
+	case FormatLRC:
+		return ParseLRC(data)
+	case FormatSRT:
+		return ParseSRT(data)
+	default:
+		return nil, ErrUnknownFormat.Withf("%q", format)
+	}
+}
+
+// Format renders synchronized lyrics in the given format. Every line must
+// have a start time.
+func Format(format string, sections []models.LyricsSection) (string, error) {
+	switch format {
+	case FormatLRC:
+		return FormatLRCText(sections)
+	case FormatSRT:
+		return FormatSRTText(sections)
+	default:
+		return "", ErrUnknownFormat.Withf("%q", format)
+	}
+}
+
+// validateMonotonic makes sure every line has a start time and that start
+// times never go backwards.
+func validateMonotonic(sections []models.LyricsSection) error {
+	var prev int64 = -1
+
+	for i, section := range sections {
+		for j, line := range section.Lines {
+			if line.StartMs == nil {
+				return ErrNotSynced.Withf("section %d line %d", i, j)
+			}
+
+			if *line.StartMs < prev {
+				return ErrNonMonotonic.Withf("section %d line %d starts before the previous line", i, j)
+			}
+			prev = *line.StartMs
+		}
+	}
+
+	return nil
+}
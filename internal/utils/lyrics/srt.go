@@ -0,0 +1,106 @@
+package lyrics
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// srtLastCueMs is how long the last cue stays on screen, SRT requires an end
+// time but lyrics lines only have start times.
+const srtLastCueMs = 4000
+
+var (
+	srtBlockSeparator = regexp.MustCompile(`\n([ \t]*\n)+`)
+	srtTiming         = regexp.MustCompile(
+		`^(\d{2,}):(\d{2}):(\d{2})[,.](\d{3})\s*-->\s*(\d{2,}):(\d{2}):(\d{2})[,.](\d{3})`,
+	)
+)
+
+// ParseSRT parses SubRip subtitles. Every text line of a cue becomes a
+// lyrics line starting at the cue start time. SRT has no notion of sections,
+// so all lines end up in a single verse section.
+func ParseSRT(data string) ([]models.LyricsSection, error) {
+	data = strings.TrimSpace(strings.TrimPrefix(strings.ReplaceAll(data, "\r\n", "\n"), "\ufeff"))
+	if data == "" {
+		return nil, ErrInvalidFormat.Withf("no cues")
+	}
+
+	section := models.LyricsSection{Type: models.SectionVerse}
+
+	for n, block := range srtBlockSeparator.Split(data, -1) {
+		lines := strings.Split(block, "\n")
+		if len(lines) < 3 {
+			return nil, ErrInvalidFormat.Withf("cue %d: expected index, timing and text", n+1)
+		}
+
+		if _, err := strconv.Atoi(strings.TrimSpace(lines[0])); err != nil {
+			return nil, ErrInvalidFormat.Withf("cue %d: bad index", n+1)
+		}
+
+		match := srtTiming.FindStringSubmatch(strings.TrimSpace(lines[1]))
+		if match == nil {
+			return nil, ErrInvalidFormat.Withf("cue %d: bad timing", n+1)
+		}
+
+		startMs := srtTimestamp(match[1:5])
+		if srtTimestamp(match[5:9]) < startMs {
+			return nil, ErrNonMonotonic.Withf("cue %d ends before it starts", n+1)
+		}
+
+		for _, text := range lines[2:] {
+			start := startMs
+			section.Lines = append(section.Lines, models.LyricsLine{Text: strings.TrimSpace(text), StartMs: &start})
+		}
+	}
+
+	sections := []models.LyricsSection{section}
+
+	if err := validateMonotonic(sections); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+// FormatSRTText renders lyrics as SubRip, one cue per line. A cue lasts until
+// the next line starts.
+func FormatSRTText(sections []models.LyricsSection) (string, error) {
+	if err := validateMonotonic(sections); err != nil {
+		return "", err
+	}
+
+	var lines []models.LyricsLine
+	for _, section := range sections {
+		lines = append(lines, section.Lines...)
+	}
+
+	var b strings.Builder
+
+	for i, line := range lines {
+		start := *line.StartMs
+		end := start + srtLastCueMs
+		if i+1 < len(lines) && *lines[i+1].StartMs > start {
+			end = *lines[i+1].StartMs
+		}
+
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, srtFormatTimestamp(start), srtFormatTimestamp(end), line.Text)
+	}
+
+	return b.String(), nil
+}
+
+func srtTimestamp(parts []string) int64 {
+	hours, _ := strconv.ParseInt(parts[0], 10, 64)
+	minutes, _ := strconv.ParseInt(parts[1], 10, 64)
+	seconds, _ := strconv.ParseInt(parts[2], 10, 64)
+	millis, _ := strconv.ParseInt(parts[3], 10, 64)
+
+	return hours*3600000 + minutes*60000 + seconds*1000 + millis
+}
+
+func srtFormatTimestamp(ms int64) string {
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
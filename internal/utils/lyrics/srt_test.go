@@ -0,0 +1,108 @@
+package lyrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSRT(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    [][]timedLine
+		wantErr error
+	}{
+		{
+			name: "single line cues",
+			data: "1\n00:00:01,000 --> 00:00:02,500\nHello\n\n2\n00:01:02,345 --> 00:01:04,000\nWorld\n",
+			want: [][]timedLine{{{"Hello", 1000}, {"World", 62345}}},
+		},
+		{
+			name: "multi-line cue",
+			data: "1\n00:00:01,000 --> 00:00:03,000\nfirst\n  second  \n\n2\n00:00:03,000 --> 00:00:04,000\nthird",
+			want: [][]timedLine{{{"first", 1000}, {"second", 1000}, {"third", 3000}}},
+		},
+		{
+			name: "hours and period separator",
+			data: "1\n01:00:00.250 --> 01:00:01.000\na",
+			want: [][]timedLine{{{"a", 3600250}}},
+		},
+		{
+			name: "BOM, CRLF and several blank lines between cues",
+			data: "\ufeff1\r\n00:00:01,000 --> 00:00:02,000\r\na\r\n\r\n \r\n\r\n2\r\n00:00:02,000 --> 00:00:03,000\r\nb\r\n",
+			want: [][]timedLine{{{"a", 1000}, {"b", 2000}}},
+		},
+		{
+			name:    "cues out of order",
+			data:    "1\n00:00:05,000 --> 00:00:06,000\na\n\n2\n00:00:01,000 --> 00:00:02,000\nb",
+			wantErr: ErrNonMonotonic,
+		},
+		{
+			name:    "cue ends before it starts",
+			data:    "1\n00:00:05,000 --> 00:00:04,000\na",
+			wantErr: ErrNonMonotonic,
+		},
+		{
+			name:    "cue without text",
+			data:    "1\n00:00:01,000 --> 00:00:02,000",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "bad index",
+			data:    "first\n00:00:01,000 --> 00:00:02,000\na",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "bad timing",
+			data:    "1\n00:00:01 --> 00:00:02\na",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "missing arrow",
+			data:    "1\n00:00:01,000 00:00:02,000\na",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "empty input",
+			data:    " \n\n",
+			wantErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := ParseSRT(tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseSRT() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSRT() error = %v", err)
+			}
+
+			if got := timed(t, sections); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSRT() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSRTText(t *testing.T) {
+	sections, err := ParseLRC("[00:01.00]a\n\n[01:02.34]b")
+	if err != nil {
+		t.Fatalf("ParseLRC() error = %v", err)
+	}
+
+	got, err := FormatSRTText(sections)
+	if err != nil {
+		t.Fatalf("FormatSRTText() error = %v", err)
+	}
+
+	const want = "1\n00:00:01,000 --> 00:01:02,340\na\n\n2\n00:01:02,340 --> 00:01:06,340\nb\n\n"
+	if got != want {
+		t.Errorf("FormatSRTText() = %q, want %q", got, want)
+	}
+}
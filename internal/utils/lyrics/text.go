@@ -0,0 +1,59 @@
+package lyrics
+
+import (
+	"effectivemobiletesttask/internal/domain/models"
+	"regexp"
+	"strings"
+)
+
+// verseSeparator and the trimmed characters match the split of migration 3,
+// so verses read from the text and from the stored sections agree.
+var verseSeparator = regexp.MustCompile(`\n([ \t]*\n)+`)
+
+// SplitVerses splits song text into verses separated by blank lines and each
+// verse into its lines. Lines holding only spaces or tabs count as blank.
+func SplitVerses(text string) [][]string {
+	text = strings.Trim(strings.ReplaceAll(text, "\r\n", "\n"), " \t\n")
+	if text == "" {
+		return nil
+	}
+
+	var verses [][]string
+	for _, verse := range verseSeparator.Split(text, -1) {
+		verses = append(verses, strings.Split(verse, "\n"))
+	}
+
+	return verses
+}
+
+// FromText builds untimed lyrics sections from plain text, every verse
+// becoming a section of type verse.
+func FromText(text string) []models.LyricsSection {
+	sections := []models.LyricsSection{}
+
+	for _, verse := range SplitVerses(text) {
+		section := models.LyricsSection{Type: models.SectionVerse}
+		for _, line := range verse {
+			section.Lines = append(section.Lines, models.LyricsLine{Text: line})
+		}
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+// ToText renders sections back into plain text with verses separated by a
+// blank line, the format the songs text column is stored in.
+func ToText(sections []models.LyricsSection) string {
+	verses := make([]string, 0, len(sections))
+
+	for _, section := range sections {
+		lines := make([]string, 0, len(section.Lines))
+		for _, line := range section.Lines {
+			lines = append(lines, line.Text)
+		}
+		verses = append(verses, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(verses, "\n\n")
+}
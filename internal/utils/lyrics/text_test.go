@@ -0,0 +1,66 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitVerses(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want [][]string
+	}{
+		{
+			name: "single blank line",
+			text: "a\nb\n\nc",
+			want: [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name: "several blank lines",
+			text: "a\n\n\n\nb",
+			want: [][]string{{"a"}, {"b"}},
+		},
+		{
+			name: "lines of spaces and tabs",
+			text: "a\n \t\n\t\n  \nb",
+			want: [][]string{{"a"}, {"b"}},
+		},
+		{
+			name: "CRLF line endings",
+			text: "a\r\nb\r\n\r\n \r\nc\r\n",
+			want: [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name: "surrounding blank lines",
+			text: "\n \n\ta\nb\n\n",
+			want: [][]string{{"a", "b"}},
+		},
+		{
+			name: "indentation inside a verse is kept",
+			text: "a\n  b\n\nc",
+			want: [][]string{{"a", "  b"}, {"c"}},
+		},
+		{
+			name: "blank text",
+			text: " \n\t\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitVerses(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitVerses() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToText(t *testing.T) {
+	const text = "a\nb\n\nc"
+
+	if got := ToText(FromText("\n" + text + "\n\n \n")); got != text {
+		t.Errorf("ToText(FromText()) = %q, want %q", got, text)
+	}
+}
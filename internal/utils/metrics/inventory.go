@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	StateLive  = "live"
+	StateTrash = "trash"
+)
+
+// Inventory counts the songs and groups, live and in the trash.
+type Inventory struct {
+	Songs  map[string]int64
+	Groups map[string]int64
+}
+
+type InventoryCounter interface {
+	CountInventory(ctx context.Context) (Inventory, error)
+}
+
+var (
+	songsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "songs"),
+		"Songs in the library by state.",
+		[]string{"state"}, nil,
+	)
+	groupsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "groups"),
+		"Groups in the library by state.",
+		[]string{"state"}, nil,
+	)
+)
+
+// inventoryCollector counts the songs and groups on every scrape.
+type inventoryCollector struct {
+	counter InventoryCounter
+	timeout time.Duration
+}
+
+// NewInventoryCollector reports the songs and groups counted by counter. A
+// scrape gives the count timeout to complete; failed counts are reported as
+// invalid metrics so that the scrape shows the error.
+func NewInventoryCollector(counter InventoryCounter, timeout time.Duration) prometheus.Collector {
+	return &inventoryCollector{counter: counter, timeout: timeout}
+}
+
+func (c *inventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- songsDesc
+	ch <- groupsDesc
+}
+
+func (c *inventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	inventory, err := c.counter.CountInventory(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(songsDesc, err)
+		ch <- prometheus.NewInvalidMetric(groupsDesc, err)
+		return
+	}
+
+	for state, count := range inventory.Songs {
+		ch <- prometheus.MustNewConstMetric(songsDesc, prometheus.GaugeValue, float64(count), state)
+	}
+	for state, count := range inventory.Groups {
+		ch <- prometheus.MustNewConstMetric(groupsDesc, prometheus.GaugeValue, float64(count), state)
+	}
+}
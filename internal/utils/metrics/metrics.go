@@ -0,0 +1,91 @@
+// Package metrics holds the Prometheus metrics of the service. Every layer
+// records into the collectors of this package, and Handler serves them all.
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "songlib"
+
+// Registry is the registry Handler serves. It includes the Go runtime and
+// process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	QueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of the storage operations by query.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"query"})
+
+	UpstreamRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "upstream",
+		Name:      "requests_total",
+		Help:      "Requests to the song info API by outcome.",
+	}, []string{"outcome"})
+
+	UpstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "upstream",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the requests to the song info API by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		QueryDuration,
+		UpstreamRequests,
+		UpstreamDuration,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveQuery records the latency of the storage operation op, named after
+// its last dot-separated part. Call it deferred:
+//
+//	defer metrics.ObserveQuery(op, time.Now())
+func ObserveQuery(op string, start time.Time) {
+	query := op[strings.LastIndex(op, ".")+1:]
+	QueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
+
+// ObserveUpstream records a request to the song info API.
+func ObserveUpstream(outcome string, duration time.Duration) {
+	UpstreamRequests.WithLabelValues(outcome).Inc()
+	UpstreamDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
@@ -0,0 +1,101 @@
+// Package ratelimit limits clients with token buckets. A bucket holds up to
+// Burst tokens and refills at Rate tokens per second; every request takes
+// one token and is refused while the bucket is empty.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+type Bucket struct {
+	Rate  float64
+	Burst int
+}
+
+// NewBucket allows requests per period on average and burst at once.
+func NewBucket(requests int, per time.Duration, burst int) Bucket {
+	if per <= 0 {
+		return Bucket{}
+	}
+
+	return Bucket{Rate: float64(requests) / per.Seconds(), Burst: burst}
+}
+
+// Enabled reports whether the bucket limits anything. A bucket without rate
+// or burst lets every request through.
+func (b Bucket) Enabled() bool {
+	return b.Rate > 0 && b.Burst > 0
+}
+
+// State is what a store keeps per bucket key.
+type State struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result describes a token request. Reset is how long the bucket takes to
+// fill up again, RetryAfter how long a refused client has to wait.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Take refills state up to now and takes a token when there is one. The zero
+// State is a full bucket.
+func (b Bucket) Take(state State, now time.Time) (State, Result) {
+	tokens := b.refill(state, now)
+
+	res := Result{Limit: b.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = b.refillTime(1 - tokens)
+	}
+
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = b.refillTime(float64(b.Burst) - tokens)
+
+	return State{Tokens: tokens, UpdatedAt: now}, res
+}
+
+// Peek reports what Take would answer at now without taking a token.
+func (b Bucket) Peek(state State, now time.Time) Result {
+	tokens := b.refill(state, now)
+
+	res := Result{
+		Allowed:   tokens >= 1,
+		Limit:     b.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     b.refillTime(float64(b.Burst) - tokens),
+	}
+	if !res.Allowed {
+		res.RetryAfter = b.refillTime(1 - tokens)
+	}
+
+	return res
+}
+
+// refill is the number of tokens in the bucket at now.
+func (b Bucket) refill(state State, now time.Time) float64 {
+	if state.UpdatedAt.IsZero() {
+		return float64(b.Burst)
+	}
+
+	elapsed := max(now.Sub(state.UpdatedAt).Seconds(), 0)
+	return min(float64(b.Burst), state.Tokens+elapsed*b.Rate)
+}
+
+// FillTime is how long an empty bucket takes to fill up. A bucket idle for
+// longer is full and need not be stored.
+func (b Bucket) FillTime() time.Duration {
+	return b.refillTime(float64(b.Burst))
+}
+
+func (b Bucket) refillTime(tokens float64) time.Duration {
+	return time.Duration(tokens / b.Rate * float64(time.Second))
+}
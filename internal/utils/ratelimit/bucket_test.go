@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// TestTake covers the refill math shared by the stores. The Postgres store
+// inserts a full bucket stamped with the database clock and passes that
+// clock as now.
+func TestTake(t *testing.T) {
+	bucket := NewBucket(2, time.Second, 4)
+
+	tests := []struct {
+		name      string
+		state     State
+		now       time.Time
+		wantState State
+		want      Result
+	}{
+		{
+			name:      "zero state is a full bucket",
+			now:       t0,
+			wantState: State{Tokens: 3, UpdatedAt: t0},
+			want:      Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond},
+		},
+		{
+			name:      "stored full bucket",
+			state:     State{Tokens: 4, UpdatedAt: t0},
+			now:       t0,
+			wantState: State{Tokens: 3, UpdatedAt: t0},
+			want:      Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond},
+		},
+		{
+			name:      "last token",
+			state:     State{Tokens: 1.5, UpdatedAt: t0},
+			now:       t0,
+			wantState: State{Tokens: 0.5, UpdatedAt: t0},
+			want:      Result{Allowed: true, Limit: 4, Remaining: 0, Reset: 1750 * time.Millisecond},
+		},
+		{
+			name:      "empty bucket",
+			state:     State{Tokens: 0.5, UpdatedAt: t0},
+			now:       t0,
+			wantState: State{Tokens: 0.5, UpdatedAt: t0},
+			want:      Result{Limit: 4, Reset: 1750 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+		},
+		{
+			name:      "refills at the rate",
+			state:     State{Tokens: 0, UpdatedAt: t0},
+			now:       t0.Add(500 * time.Millisecond),
+			wantState: State{Tokens: 0, UpdatedAt: t0.Add(500 * time.Millisecond)},
+			want:      Result{Allowed: true, Limit: 4, Remaining: 0, Reset: 2 * time.Second},
+		},
+		{
+			name:      "partial refill is not enough",
+			state:     State{Tokens: 0, UpdatedAt: t0},
+			now:       t0.Add(250 * time.Millisecond),
+			wantState: State{Tokens: 0.5, UpdatedAt: t0.Add(250 * time.Millisecond)},
+			want:      Result{Limit: 4, Reset: 1750 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+		},
+		{
+			name:      "refill stops at burst",
+			state:     State{Tokens: 1, UpdatedAt: t0},
+			now:       t0.Add(time.Hour),
+			wantState: State{Tokens: 3, UpdatedAt: t0.Add(time.Hour)},
+			want:      Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond},
+		},
+		{
+			name:      "clock going backwards refills nothing",
+			state:     State{Tokens: 0.5, UpdatedAt: t0},
+			now:       t0.Add(-time.Second),
+			wantState: State{Tokens: 0.5, UpdatedAt: t0.Add(-time.Second)},
+			want:      Result{Limit: 4, Reset: 1750 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, res := bucket.Take(tt.state, tt.now)
+			if state != tt.wantState {
+				t.Errorf("Take() state = %+v, want %+v", state, tt.wantState)
+			}
+			if res != tt.want {
+				t.Errorf("Take() result = %+v, want %+v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBucket(t *testing.T) {
+	tests := []struct {
+		name        string
+		bucket      Bucket
+		wantRate    float64
+		wantEnabled bool
+		wantFill    time.Duration
+	}{
+		{"per minute", NewBucket(60, time.Minute, 10), 1, true, 10 * time.Second},
+		{"per second", NewBucket(4, time.Second, 2), 4, true, 500 * time.Millisecond},
+		{"no period", NewBucket(10, 0, 10), 0, false, 0},
+		{"no burst", NewBucket(10, time.Second, 0), 10, false, 0},
+		{"no requests", NewBucket(0, time.Second, 10), 0, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.bucket.Rate != tt.wantRate {
+				t.Errorf("Rate = %v, want %v", tt.bucket.Rate, tt.wantRate)
+			}
+			if tt.bucket.Enabled() != tt.wantEnabled {
+				t.Errorf("Enabled() = %v, want %v", tt.bucket.Enabled(), tt.wantEnabled)
+			}
+			if tt.wantEnabled && tt.bucket.FillTime() != tt.wantFill {
+				t.Errorf("FillTime() = %v, want %v", tt.bucket.FillTime(), tt.wantFill)
+			}
+		})
+	}
+}
+
+func TestPeek(t *testing.T) {
+	bucket := NewBucket(2, time.Second, 4)
+
+	tests := []struct {
+		name  string
+		state State
+		now   time.Time
+		want  Result
+	}{
+		{
+			name: "zero state is a full bucket",
+			now:  t0,
+			want: Result{Allowed: true, Limit: 4, Remaining: 4},
+		},
+		{
+			name:  "last token",
+			state: State{Tokens: 1, UpdatedAt: t0},
+			now:   t0,
+			want:  Result{Allowed: true, Limit: 4, Remaining: 1, Reset: 1500 * time.Millisecond},
+		},
+		{
+			name:  "empty bucket",
+			state: State{Tokens: 0.5, UpdatedAt: t0},
+			now:   t0,
+			want:  Result{Limit: 4, Reset: 1750 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+		},
+		{
+			name:  "refilled",
+			state: State{Tokens: 0.5, UpdatedAt: t0},
+			now:   t0.Add(250 * time.Millisecond),
+			want:  Result{Allowed: true, Limit: 4, Remaining: 1, Reset: 1500 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bucket.Peek(tt.state, tt.now); got != tt.want {
+				t.Errorf("Peek() = %+v, want %+v", got, tt.want)
+			}
+
+			// Peeking takes nothing: Take still finds the same tokens.
+			if _, res := bucket.Take(tt.state, tt.now); res.Allowed != tt.want.Allowed {
+				t.Errorf("Take() allowed = %v after Peek() allowed %v", res.Allowed, tt.want.Allowed)
+			}
+		})
+	}
+}
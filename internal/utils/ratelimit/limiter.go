@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	lg "effectivemobiletesttask/internal/utils/logger"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const purgeInterval = time.Minute
+
+type Store interface {
+	// TakeToken takes a token from the bucket stored under key.
+	TakeToken(ctx context.Context, key string, bucket Bucket) (Result, error)
+	// PeekToken reports whether the bucket stored under key has a token
+	// without taking it.
+	PeekToken(ctx context.Context, key string, bucket Bucket) (Result, error)
+	// PurgeRateLimits drops buckets unused for longer than idle.
+	PurgeRateLimits(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+// Limiter keeps a bucket per route class and client. Between Start and Stop
+// it drops idle buckets once per purge interval.
+type Limiter struct {
+	log      *slog.Logger
+	store    Store
+	buckets  map[string]Bucket
+	idle     time.Duration
+	interval time.Duration
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// New limits each class with its bucket. Classes without an enabled bucket
+// are not limited.
+func New(log *slog.Logger, store Store, buckets map[string]Bucket) *Limiter {
+	l := &Limiter{
+		log:      log,
+		store:    store,
+		buckets:  make(map[string]Bucket, len(buckets)),
+		interval: purgeInterval,
+	}
+
+	for class, bucket := range buckets {
+		if bucket.Enabled() {
+			l.buckets[class] = bucket
+			l.idle = max(l.idle, bucket.FillTime())
+		}
+	}
+
+	return l
+}
+
+// Allow takes a token from the bucket of the client in class. ok is false
+// when the class is not limited.
+func (l *Limiter) Allow(ctx context.Context, class string, client string) (res Result, ok bool, err error) {
+	const op = "utils.ratelimit.Allow"
+
+	bucket, ok := l.buckets[class]
+	if !ok {
+		return Result{}, false, nil
+	}
+
+	res, err = l.store.TakeToken(ctx, class+":"+client, bucket)
+	if err != nil {
+		return Result{}, true, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, true, nil
+}
+
+// Check reports whether Allow would let the client in class through,
+// without taking a token.
+func (l *Limiter) Check(ctx context.Context, class string, client string) (res Result, ok bool, err error) {
+	const op = "utils.ratelimit.Check"
+
+	bucket, ok := l.buckets[class]
+	if !ok {
+		return Result{}, false, nil
+	}
+
+	res, err = l.store.PeekToken(ctx, class+":"+client, bucket)
+	if err != nil {
+		return Result{}, true, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, true, nil
+}
+
+// Start runs the purge job in the background until Stop.
+func (l *Limiter) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	l.wg.Add(1)
+	go l.run(ctx)
+}
+
+// Stop cancels the purge job and waits for a purge in progress, until ctx
+// is done.
+func (l *Limiter) Stop(ctx context.Context) error {
+	const op = "utils.ratelimit.Stop"
+
+	if l.cancel == nil {
+		return nil
+	}
+
+	l.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
+
+// run drops idle buckets once per interval. Idle buckets are full, dropping
+// them changes no limit.
+func (l *Limiter) run(ctx context.Context) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := l.store.PurgeRateLimits(ctx, l.idle)
+		if err != nil {
+			l.log.ErrorContext(ctx, "error purging rate limits", lg.Err(err))
+			continue
+		}
+
+		l.log.DebugContext(ctx, "purged rate limits", slog.Int64("buckets", purged))
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// purgeCounter reports every purge of its store.
+type purgeCounter struct {
+	*MemoryStore
+	purges chan time.Duration
+}
+
+func (s *purgeCounter) PurgeRateLimits(ctx context.Context, idle time.Duration) (int64, error) {
+	s.purges <- idle
+	return s.MemoryStore.PurgeRateLimits(ctx, idle)
+}
+
+func TestLimiterPurge(t *testing.T) {
+	store := &purgeCounter{MemoryStore: NewMemoryStore(), purges: make(chan time.Duration)}
+	l := New(slog.New(slog.NewTextHandler(io.Discard, nil)), store, map[string]Bucket{
+		"read":  NewBucket(1, time.Second, 10),
+		"write": NewBucket(1, time.Second, 30),
+		"off":   NewBucket(0, time.Second, 60),
+	})
+	l.interval = time.Millisecond
+
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() before Start() error = %v", err)
+	}
+
+	l.Start()
+
+	// Buckets are idle once the slowest enabled one has refilled.
+	for range 2 {
+		select {
+		case idle := <-store.purges:
+			if idle != 30*time.Second {
+				t.Errorf("PurgeRateLimits() idle = %v, want %v", idle, 30*time.Second)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no purge within a second")
+		}
+	}
+
+	stopped := make(chan error)
+	go func() { stopped <- l.Stop(context.Background()) }()
+
+	// A purge may already be waiting on the channel when Stop cancels.
+	for {
+		select {
+		case <-store.purges:
+			continue
+		case err := <-stopped:
+			if err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Stop() did not return within a second")
+		}
+		break
+	}
+
+	select {
+	case <-store.purges:
+		t.Errorf("purged after Stop()")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the buckets of this process only. Each replica then
+// limits clients on its own.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+	now    func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]State), now: time.Now}
+}
+
+func (s *MemoryStore) TakeToken(_ context.Context, key string, bucket Bucket) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, res := bucket.Take(s.states[key], s.now())
+	s.states[key] = state
+
+	return res, nil
+}
+
+func (s *MemoryStore) PeekToken(_ context.Context, key string, bucket Bucket) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return bucket.Peek(s.states[key], s.now()), nil
+}
+
+func (s *MemoryStore) PurgeRateLimits(_ context.Context, idle time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-idle)
+
+	var purged int64
+	for key, state := range s.states {
+		if state.UpdatedAt.Before(cutoff) {
+			delete(s.states, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	bucket := NewBucket(1, time.Second, 2)
+
+	now := t0
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	steps := []struct {
+		name           string
+		advance        time.Duration
+		key            string
+		wantAllowed    bool
+		wantRemaining  int
+		wantRetryAfter time.Duration
+	}{
+		{"first request", 0, "a", true, 1, 0},
+		{"burst", 0, "a", true, 0, 0},
+		{"empty", 0, "a", false, 0, time.Second},
+		{"other key has its own bucket", 0, "b", true, 1, 0},
+		{"half refilled", 500 * time.Millisecond, "a", false, 0, 500 * time.Millisecond},
+		{"refilled", 500 * time.Millisecond, "a", true, 0, 0},
+		{"full after idling", time.Minute, "a", true, 1, 0},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		res, err := s.TakeToken(ctx, step.key, bucket)
+		if err != nil {
+			t.Fatalf("%s: TakeToken() error = %v", step.name, err)
+		}
+
+		if res.Allowed != step.wantAllowed || res.Remaining != step.wantRemaining || res.RetryAfter != step.wantRetryAfter {
+			t.Errorf("%s: TakeToken() = %+v, want allowed %v, remaining %d, retry after %v",
+				step.name, res, step.wantAllowed, step.wantRemaining, step.wantRetryAfter)
+		}
+	}
+
+	// Bucket b was last used a minute and a second ago, bucket a just now.
+	purged, err := s.PurgeRateLimits(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("PurgeRateLimits() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeRateLimits() = %d, want 1", purged)
+	}
+	if _, ok := s.states["a"]; !ok {
+		t.Errorf("PurgeRateLimits() dropped the bucket in use")
+	}
+}
@@ -0,0 +1,163 @@
+// Package validate checks structs against declarative rules given in their
+// `validate` tags, e.g. `validate:"required,max=255"`.
+//
+// Supported rules:
+//
+//	required   the value must not be empty
+//	max=N      a string must not be longer than N characters
+//	url        a non-empty string must be an absolute http or https URL
+//	date       a non-empty string must be a YYYY-MM-DD date
+//	notfuture  a date (string or time.Time) must not be after today
+//
+// Every string field with a tag is trimmed and normalised to Unicode NFC
+// before the rules run, so the caller stores what was validated.
+package validate
+
+import (
+	"effectivemobiletesttask/internal/domain/errs"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const dateLayout = "2006-01-02"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Struct normalises and validates the struct v points to. It returns every
+// violation, named by the JSON name of the field; nil means v is valid.
+func Struct(v any) []errs.FieldError {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: expected pointer to struct, got %T", v))
+	}
+
+	var violations []errs.FieldError
+	walk(value.Elem(), "", &violations)
+
+	return violations
+}
+
+func walk(value reflect.Value, prefix string, violations *[]errs.FieldError) {
+	typ := value.Type()
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			walk(fieldValue, prefix, violations)
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		name := prefix + jsonName(field)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			walk(fieldValue, name+".", violations)
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.String {
+			fieldValue.SetString(normalise(fieldValue.String()))
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			if message := check(rule, fieldValue); message != "" {
+				*violations = append(*violations, errs.Field(name, message))
+
+				if rule == "required" {
+					break
+				}
+			}
+		}
+	}
+}
+
+func check(rule string, value reflect.Value) string {
+	rule, param, _ := strings.Cut(rule, "=")
+
+	switch rule {
+	case "required":
+		if value.IsZero() {
+			return "field is required"
+		}
+	case "max":
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			panic(fmt.Sprintf("validate: invalid max %q", param))
+		}
+		if utf8.RuneCountInString(value.String()) > limit {
+			return fmt.Sprintf("must be at most %d characters long", limit)
+		}
+	case "url":
+		if link := value.String(); link != "" && !isURL(link) {
+			return "must be an absolute http or https URL"
+		}
+	case "date":
+		if date := value.String(); date != "" {
+			if _, err := time.Parse(dateLayout, date); err != nil {
+				return "has invalid format, use YYYY-MM-DD"
+			}
+		}
+	case "notfuture":
+		if date, ok := dateOf(value); ok && date.After(today()) {
+			return "must not be in the future"
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+
+	return ""
+}
+
+func normalise(value string) string {
+	return norm.NFC.String(strings.TrimSpace(value))
+}
+
+func isURL(link string) bool {
+	parsed, err := url.ParseRequestURI(link)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func dateOf(value reflect.Value) (time.Time, bool) {
+	if value.Type() == timeType {
+		date := value.Interface().(time.Time)
+		return date, !date.IsZero()
+	}
+
+	date, err := time.Parse(dateLayout, value.String())
+	return date, err == nil
+}
+
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
@@ -0,0 +1,171 @@
+package validate
+
+import (
+	"effectivemobiletesttask/internal/domain/errs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Detail struct {
+	ReleaseDate string `json:"releaseDate" validate:"date,notfuture"`
+	Link        string `json:"link" validate:"url"`
+}
+
+type album struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type song struct {
+	Group  string    `json:"group" validate:"required,max=5"`
+	Name   string    `json:"song,omitempty" validate:"required"`
+	Note   string    `validate:"max=3"`
+	Added  time.Time `json:"added" validate:"notfuture"`
+	Album  album     `json:"album" validate:""`
+	Hidden string    `json:"-" validate:"max=1"`
+	Free   string    `json:"free"`
+	Skip   string    `json:"skip" validate:"-"`
+	Detail
+	secret string
+}
+
+func TestStruct(t *testing.T) {
+	valid := song{Group: "Muse", Name: "Uprising", Album: album{Title: "The Resistance"}}
+
+	tests := []struct {
+		name   string
+		modify func(*song)
+		want   []errs.FieldError
+	}{
+		{
+			name:   "valid",
+			modify: func(*song) {},
+		},
+		{
+			name: "every violation is reported",
+			modify: func(s *song) {
+				s.Group = ""
+				s.Name = ""
+				s.Note = "long"
+				s.Album.Title = ""
+				s.Hidden = "ab"
+				s.Detail = Detail{ReleaseDate: "16.07.2006", Link: "ftp://example.com"}
+			},
+			want: []errs.FieldError{
+				errs.Field("group", "field is required"),
+				errs.Field("song", "field is required"),
+				errs.Field("Note", "must be at most 3 characters long"),
+				errs.Field("album.title", "field is required"),
+				errs.Field("Hidden", "must be at most 1 characters long"),
+				errs.Field("releaseDate", "has invalid format, use YYYY-MM-DD"),
+				errs.Field("link", "must be an absolute http or https URL"),
+			},
+		},
+		{
+			name:   "required stops the other rules of the field",
+			modify: func(s *song) { s.Group = "   " },
+			want:   []errs.FieldError{errs.Field("group", "field is required")},
+		},
+		{
+			name:   "max counts characters, not bytes",
+			modify: func(s *song) { s.Group = "Ёлка" },
+		},
+		{
+			name:   "max",
+			modify: func(s *song) { s.Group = "Nirvana" },
+			want:   []errs.FieldError{errs.Field("group", "must be at most 5 characters long")},
+		},
+		{
+			name:   "past dates",
+			modify: func(s *song) { s.ReleaseDate = "2006-07-16"; s.Added = time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC) },
+		},
+		{
+			name: "future dates",
+			modify: func(s *song) {
+				s.ReleaseDate = time.Now().AddDate(1, 0, 0).Format(dateLayout)
+				s.Added = time.Now().AddDate(1, 0, 0)
+			},
+			want: []errs.FieldError{
+				errs.Field("added", "must not be in the future"),
+				errs.Field("releaseDate", "must not be in the future"),
+			},
+		},
+		{
+			name:   "URLs",
+			modify: func(s *song) { s.Link = "https://www.youtube.com/watch?v=Xsp3_a-PMTw" },
+		},
+		{
+			name:   "relative URL",
+			modify: func(s *song) { s.Link = "/watch?v=Xsp3_a-PMTw" },
+			want:   []errs.FieldError{errs.Field("link", "must be an absolute http or https URL")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+
+			if got := Struct(&s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructNormalises(t *testing.T) {
+	s := song{
+		Group:  " Bjo\u0308rk \n",
+		Name:   "\tUprising ",
+		Album:  album{Title: " The Resistance "},
+		Free:   " untouched ",
+		secret: " untouched ",
+	}
+
+	// The decomposed name is 6 runes long: max=5 only passes after NFC.
+	if got := Struct(&s); got != nil {
+		t.Fatalf("Struct() = %v, want nil", got)
+	}
+
+	want := song{
+		Group:  "Bj\u00f6rk",
+		Name:   "Uprising",
+		Album:  album{Title: "The Resistance"},
+		Free:   " untouched ",
+		secret: " untouched ",
+	}
+	if s != want {
+		t.Errorf("Struct() left %+v, want %+v", s, want)
+	}
+}
+
+func TestStructPanics(t *testing.T) {
+	type unknownRule struct {
+		Name string `validate:"required,email"`
+	}
+	type badMax struct {
+		Name string `validate:"max=many"`
+	}
+
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"unknown rule", &unknownRule{Name: "a"}},
+		{"invalid max", &badMax{}},
+		{"not a pointer", unknownRule{}},
+		{"pointer to a non-struct", new(string)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Struct() did not panic")
+				}
+			}()
+
+			Struct(tt.v)
+		})
+	}
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_authz_denials_created_at;
+DROP TABLE IF EXISTS authz_denials;
+DROP TABLE IF EXISTS role_grants;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
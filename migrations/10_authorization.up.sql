@@ -0,0 +1,27 @@
+-- Roles and grants are synced from the config on start. Grants are keyed by
+-- subject, an API key name or a JWT sub, so they survive key rotation.
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(64) PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(64) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS role_grants (
+    subject VARCHAR(255) NOT NULL,
+    role VARCHAR(64) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    PRIMARY KEY (subject, role)
+);
+
+CREATE TABLE IF NOT EXISTS authz_denials (
+    id BIGSERIAL PRIMARY KEY,
+    subject VARCHAR(255) NOT NULL,
+    permission VARCHAR(64) NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_authz_denials_created_at ON authz_denials (created_at);
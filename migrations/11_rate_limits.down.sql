@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_rate_limits_updated_at;
+DROP TABLE IF EXISTS rate_limits;
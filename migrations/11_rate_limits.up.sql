@@ -0,0 +1,9 @@
+-- Token buckets shared by all replicas. A bucket is keyed by route class and
+-- client; rows idle long enough to be full again are purged.
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key VARCHAR(512) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_updated_at ON rate_limits (updated_at);
@@ -0,0 +1,14 @@
+INSERT INTO role_grants (subject, role)
+SELECT k.name, g.role
+FROM role_grants g
+JOIN api_keys k ON g.subject = 'key:' || k.id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_grants (subject, role)
+SELECT substr(subject, 5), role
+FROM role_grants
+WHERE subject LIKE 'jwt:%'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM role_grants
+WHERE subject LIKE 'key:%' OR subject LIKE 'jwt:%';
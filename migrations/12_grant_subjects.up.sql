@@ -0,0 +1,13 @@
+-- Grants were keyed by API key name or JWT sub alike, so a token whose sub
+-- matched a key name, or a new key reusing a name, inherited its roles. They
+-- are now keyed by "key:<id>" or "jwt:<sub>". Name grants move to the live
+-- keys of that name; grants of revoked keys and other subjects are dropped
+-- and the configured ones are written again on start.
+INSERT INTO role_grants (subject, role)
+SELECT 'key:' || k.id, g.role
+FROM role_grants g
+JOIN api_keys k ON k.name = g.subject AND k.revoked_at IS NULL
+ON CONFLICT DO NOTHING;
+
+DELETE FROM role_grants
+WHERE subject NOT LIKE 'key:%' AND subject NOT LIKE 'jwt:%';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_songs_text_tsv_english;
+DROP INDEX IF EXISTS idx_songs_text_tsv_russian;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS text_tsv_english,
+    DROP COLUMN IF EXISTS text_tsv_russian;
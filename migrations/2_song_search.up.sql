@@ -0,0 +1,8 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS text_tsv_russian tsvector
+        GENERATED ALWAYS AS (to_tsvector('russian', COALESCE(text, ''))) STORED,
+    ADD COLUMN IF NOT EXISTS text_tsv_english tsvector
+        GENERATED ALWAYS AS (to_tsvector('english', COALESCE(text, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_songs_text_tsv_russian ON songs USING GIN (text_tsv_russian);
+CREATE INDEX IF NOT EXISTS idx_songs_text_tsv_english ON songs USING GIN (text_tsv_english);
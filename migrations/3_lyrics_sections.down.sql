@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lyrics_lines;
+DROP TABLE IF EXISTS lyrics_sections;
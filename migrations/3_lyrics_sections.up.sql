@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS lyrics_sections (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    type VARCHAR(16) NOT NULL DEFAULT 'verse'
+        CHECK (type IN ('verse', 'chorus', 'bridge', 'intro', 'outro')),
+    UNIQUE (song_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS lyrics_lines (
+    id SERIAL PRIMARY KEY,
+    section_id INT NOT NULL REFERENCES lyrics_sections(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    text TEXT NOT NULL DEFAULT '',
+    start_ms BIGINT CHECK (start_ms >= 0),
+    UNIQUE (section_id, position)
+);
+
+-- Build sections from the existing plain text: verses are separated by blank
+-- lines, every verse becomes a 'verse' section without timings.
+INSERT INTO lyrics_sections (song_id, position, type)
+SELECT s.id, v.ord - 1, 'verse'
+FROM (
+    SELECT id, btrim(replace(text, E'\r\n', E'\n'), E' \t\n') AS body FROM songs
+) s
+CROSS JOIN LATERAL regexp_split_to_table(s.body, E'\n([ \t]*\n)+') WITH ORDINALITY AS v(body, ord)
+WHERE s.body <> '';
+
+INSERT INTO lyrics_lines (section_id, position, text)
+SELECT sec.id, l.ord - 1, l.line
+FROM (
+    SELECT id, btrim(replace(text, E'\r\n', E'\n'), E' \t\n') AS body FROM songs
+) s
+CROSS JOIN LATERAL regexp_split_to_table(s.body, E'\n([ \t]*\n)+') WITH ORDINALITY AS v(body, ord)
+JOIN lyrics_sections sec ON sec.song_id = s.id AND sec.position = v.ord - 1
+CROSS JOIN LATERAL regexp_split_to_table(v.body, E'\n') WITH ORDINALITY AS l(line, ord)
+WHERE s.body <> '';
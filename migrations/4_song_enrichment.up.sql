@@ -0,0 +1,21 @@
+-- Songs created before asynchronous enrichment were enriched synchronously.
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS enrichment_status VARCHAR(16) NOT NULL DEFAULT 'enriched'
+        CHECK (enrichment_status IN ('pending', 'enriched', 'failed'));
+
+ALTER TABLE songs ALTER COLUMN enrichment_status SET DEFAULT 'pending';
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL UNIQUE REFERENCES songs(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_run_at ON enrichment_jobs(run_at) WHERE status IN ('queued', 'running');
@@ -0,0 +1,2 @@
+-- Incremented by every write to a song, exposed to clients as the ETag.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
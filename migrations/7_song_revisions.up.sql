@@ -0,0 +1,14 @@
+-- Revisions outlive their song, so song_id has no foreign key: the history
+-- of a deleted song can still be listed and restored.
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL,
+    revision INT NOT NULL,
+    action VARCHAR(16) NOT NULL
+        CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    actor TEXT NOT NULL DEFAULT '',
+    restored_from INT,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (song_id, revision)
+);
@@ -0,0 +1,11 @@
+DELETE FROM songs WHERE deleted_at IS NOT NULL;
+DELETE FROM groups WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_groups_live_name;
+ALTER TABLE groups ADD CONSTRAINT groups_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS idx_groups_deleted_at;
+DROP INDEX IF EXISTS idx_songs_deleted_at;
+
+ALTER TABLE groups DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS deleted_at;
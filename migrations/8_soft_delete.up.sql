@@ -0,0 +1,10 @@
+-- Deleted songs and groups stay in the trash until the purge job removes
+-- them. Group names only have to be unique among live groups.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_songs_deleted_at ON songs(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_groups_deleted_at ON groups(deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE groups DROP CONSTRAINT IF EXISTS groups_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_live_name ON groups(name) WHERE deleted_at IS NULL;